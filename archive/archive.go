@@ -0,0 +1,119 @@
+// Package archive exports a storage driver subtree to a tar stream and
+// imports such streams back into a driver.
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+)
+
+// modTimeSetter is implemented by drivers that can record modification
+// times, such as the swarm driver.
+type modTimeSetter interface {
+	SetModTime(ctx context.Context, path string, t time.Time) error
+}
+
+// Export writes the subtree of d at root to w as a tar stream. Entry names
+// are the driver paths without the leading slash, so the layout of the tree
+// is preserved, and carry each file's size and modification time.
+func Export(ctx context.Context, d storagedriver.StorageDriver, root string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := d.Walk(ctx, root, func(fi storagedriver.FileInfo) error {
+		hdr := &tar.Header{
+			Name:    strings.TrimPrefix(fi.Path(), "/"),
+			ModTime: fi.ModTime(),
+			Mode:    0o644,
+		}
+		if fi.IsDir() {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+			hdr.Mode = 0o755
+			return tw.WriteHeader(hdr)
+		}
+		hdr.Typeflag = tar.TypeReg
+		hdr.Size = fi.Size()
+		return writeFile(ctx, d, tw, hdr, fi.Path())
+	})
+	if err != nil {
+		return fmt.Errorf("archive: failed to export %s: %w", root, err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("archive: failed to finish tar stream: %w", err)
+	}
+	return nil
+}
+
+// writeFile writes hdr followed by the content of path.
+func writeFile(ctx context.Context, d storagedriver.StorageDriver, tw *tar.Writer, hdr *tar.Header, path string) error {
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	r, err := d.Reader(ctx, path, 0)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	n, err := io.Copy(tw, r)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %w", path, err)
+	}
+	if n != hdr.Size {
+		return fmt.Errorf("%s changed during export: want %d bytes, got %d", path, hdr.Size, n)
+	}
+	return nil
+}
+
+// Import reads a tar stream as written by Export from r and writes every
+// regular file into d below the root path, creating directories implicitly.
+// Modification times are restored if d can record them. Import returns the
+// number of files written.
+func Import(ctx context.Context, r io.Reader, d storagedriver.StorageDriver) (int, error) {
+	tr := tar.NewReader(r)
+	var n int
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return n, nil
+		}
+		if err != nil {
+			return n, fmt.Errorf("archive: failed to read tar stream: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		p := path.Clean("/" + hdr.Name)
+		if err := importFile(ctx, tr, d, p, hdr); err != nil {
+			return n, fmt.Errorf("archive: failed to import %s: %w", p, err)
+		}
+		n++
+	}
+}
+
+func importFile(ctx context.Context, r io.Reader, d storagedriver.StorageDriver, p string, hdr *tar.Header) error {
+	w, err := d.Writer(ctx, p, false)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Cancel(ctx)
+		return err
+	}
+	if err := w.Commit(ctx); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if s, ok := d.(modTimeSetter); ok && !hdr.ModTime.IsZero() {
+		return s.SetModTime(ctx, p, hdr.ModTime)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"path"
+	"testing"
+
+	"github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src, err := swarmdriver.New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"/repo/app/a":        []byte("a1"),
+		"/repo/app/nested/b": bytes.Repeat([]byte("b"), 10000),
+		"/repo/app/empty":    {},
+		"/other/c":           []byte("c1"),
+	}
+	for p, content := range files {
+		if err := src.PutContent(ctx, p, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := Export(ctx, src, "/repo", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := swarmdriver.New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := Import(ctx, &buf, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("want 3 files imported, got %d", n)
+	}
+	for p, content := range files {
+		got, err := dst.GetContent(ctx, p)
+		if p == "/other/c" {
+			if err == nil {
+				t.Fatal("/other/c is outside the exported subtree")
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(got, content) {
+			t.Fatalf("%s: content differs, %v", p, err)
+		}
+		want, _ := src.Stat(ctx, p)
+		fi, err := dst.Stat(ctx, p)
+		if err != nil || fi.Size() != want.Size() || !fi.ModTime().Equal(want.ModTime()) {
+			t.Fatalf("%s: want %+v, got %+v, %v", p, want, fi, err)
+		}
+	}
+}
+
+func TestExportOCI(t *testing.T) {
+	ctx := context.Background()
+	d := inmemory.New()
+	put := func(content []byte) string {
+		sum := sha256.Sum256(content)
+		h := hex.EncodeToString(sum[:])
+		if err := d.PutContent(ctx, path.Join(registryRoot, "blobs/sha256", h[:2], h, "data"), content); err != nil {
+			t.Fatal(err)
+		}
+		return "sha256:" + h
+	}
+	config := []byte(`{"architecture":"amd64"}`)
+	layer := []byte("layer contents")
+	m, err := json.Marshal(manifest{
+		MediaType: "application/vnd.oci.image.manifest.v1+json",
+		Config:    &descriptor{Digest: put(config), Size: int64(len(config))},
+		Layers: []descriptor{
+			{Digest: put(layer), Size: int64(len(layer))},
+			{Digest: "sha256:" + hex.EncodeToString(make([]byte, 32)), URLs: []string{"https://example.com/foreign"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdgst := put(m)
+	link := path.Join(registryRoot, "repositories/library/app/_manifests/tags/v1/current/link")
+	if err := d.PutContent(ctx, link, []byte(mdgst)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportOCI(ctx, d, []string{"library/app"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+	entries := map[string][]byte{}
+	tr := tar.NewReader(&buf)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries[hdr.Name] = content
+	}
+	for _, name := range []string{"oci-layout", "index.json", ociBlobName(mdgst)} {
+		if _, ok := entries[name]; !ok {
+			t.Fatalf("missing %s in %v", name, entries)
+		}
+	}
+	if got := len(entries); got != 6 {
+		t.Fatalf("want oci-layout, index.json, blob directory and 3 blobs, got %d entries", got)
+	}
+	var index struct {
+		Manifests []descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(entries["index.json"], &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Manifests) != 1 || index.Manifests[0].Digest != mdgst || index.Manifests[0].Annotations[refNameAnnotation] != "library/app:v1" {
+		t.Fatalf("unexpected index %+v", index)
+	}
+}
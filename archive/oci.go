@@ -0,0 +1,255 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+)
+
+// registryRoot is where the distribution registry keeps its data.
+const registryRoot = "/docker/registry/v2"
+
+// refNameAnnotation names the tag of a manifest in an OCI index.
+const refNameAnnotation = "org.opencontainers.image.ref.name"
+
+// descriptor is an OCI content descriptor.
+type descriptor struct {
+	MediaType   string            `json:"mediaType,omitempty"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	URLs        []string          `json:"urls,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// manifest holds the fields of image manifests and indexes that reference
+// other blobs.
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    *descriptor  `json:"config,omitempty"`
+	Layers    []descriptor `json:"layers,omitempty"`
+	Manifests []descriptor `json:"manifests,omitempty"`
+}
+
+// ExportOCI writes the tagged images of the given repositories of a
+// distribution registry stored in d to w as a tar stream of an OCI image
+// layout: an oci-layout file, an index.json listing every tag as
+// "repository:tag" and the blobs below blobs/sha256. Blobs of foreign layers
+// that the registry does not hold are left out.
+func ExportOCI(ctx context.Context, d storagedriver.StorageDriver, repositories []string, w io.Writer) error {
+	e := &ociExport{d: d, tw: tar.NewWriter(w), seen: make(map[string]bool), now: time.Now()}
+	index := struct {
+		SchemaVersion int          `json:"schemaVersion"`
+		MediaType     string       `json:"mediaType"`
+		Manifests     []descriptor `json:"manifests"`
+	}{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.index.v1+json",
+		Manifests:     []descriptor{},
+	}
+	if err := e.writeBytes("oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	if err := e.writeDir("blobs/sha256/"); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	for _, repo := range repositories {
+		tags, err := e.tags(ctx, repo)
+		if err != nil {
+			return fmt.Errorf("archive: failed to list tags of %s: %w", repo, err)
+		}
+		for _, tag := range tags {
+			desc, err := e.exportTag(ctx, repo, tag)
+			if err != nil {
+				return fmt.Errorf("archive: failed to export %s:%s: %w", repo, tag, err)
+			}
+			desc.Annotations = map[string]string{refNameAnnotation: repo + ":" + tag}
+			index.Manifests = append(index.Manifests, desc)
+		}
+	}
+	buf, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("archive: failed to encode index: %w", err)
+	}
+	if err := e.writeBytes("index.json", buf); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	if err := e.tw.Close(); err != nil {
+		return fmt.Errorf("archive: failed to finish tar stream: %w", err)
+	}
+	return nil
+}
+
+// ociExport holds the state of a single ExportOCI.
+type ociExport struct {
+	d    storagedriver.StorageDriver
+	tw   *tar.Writer
+	seen map[string]bool // Digests of blobs already written.
+	now  time.Time
+}
+
+// tags lists the tags of repo, sorted.
+func (e *ociExport) tags(ctx context.Context, repo string) ([]string, error) {
+	paths, err := e.d.List(ctx, path.Join(registryRoot, "repositories", repo, "_manifests/tags"))
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(paths))
+	for _, p := range paths {
+		tags = append(tags, path.Base(p))
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// exportTag writes the manifest tagged tag in repo with everything it
+// references and returns its descriptor.
+func (e *ociExport) exportTag(ctx context.Context, repo, tag string) (descriptor, error) {
+	link, err := e.d.GetContent(ctx, path.Join(registryRoot, "repositories", repo, "_manifests/tags", tag, "current/link"))
+	if err != nil {
+		return descriptor{}, err
+	}
+	desc := descriptor{Digest: strings.TrimSpace(string(link))}
+	buf, err := e.exportManifest(ctx, desc.Digest)
+	if err != nil {
+		return descriptor{}, err
+	}
+	var m manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return descriptor{}, fmt.Errorf("failed to decode manifest %s: %w", desc.Digest, err)
+	}
+	desc.MediaType = m.MediaType
+	desc.Size = int64(len(buf))
+	return desc, nil
+}
+
+// exportManifest writes the manifest with digest dgst and, recursively, the
+// blobs it references. It returns the manifest.
+func (e *ociExport) exportManifest(ctx context.Context, dgst string) ([]byte, error) {
+	blobPath, err := registryBlobPath(dgst)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := e.d.GetContent(ctx, blobPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", dgst, err)
+	}
+	var m manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", dgst, err)
+	}
+	if m.Config != nil {
+		if err := e.exportBlob(ctx, *m.Config); err != nil {
+			return nil, err
+		}
+	}
+	for _, layer := range m.Layers {
+		if err := e.exportBlob(ctx, layer); err != nil {
+			return nil, err
+		}
+	}
+	for _, child := range m.Manifests {
+		if _, err := e.exportManifest(ctx, child.Digest); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.writeBlob(ctx, dgst, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// exportBlob writes the blob described by desc.
+func (e *ociExport) exportBlob(ctx context.Context, desc descriptor) error {
+	if e.seen[desc.Digest] {
+		return nil
+	}
+	blobPath, err := registryBlobPath(desc.Digest)
+	if err != nil {
+		return err
+	}
+	fi, err := e.d.Stat(ctx, blobPath)
+	if len(desc.URLs) > 0 && errors.As(err, new(storagedriver.PathNotFoundError)) {
+		// Foreign layers are fetched from their URLs.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat blob %s: %w", desc.Digest, err)
+	}
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     ociBlobName(desc.Digest),
+		Size:     fi.Size(),
+		Mode:     0o644,
+		ModTime:  fi.ModTime(),
+	}
+	if err := writeFile(ctx, e.d, e.tw, hdr, blobPath); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", desc.Digest, err)
+	}
+	e.seen[desc.Digest] = true
+	return nil
+}
+
+// writeBlob writes buf as the blob with digest dgst.
+func (e *ociExport) writeBlob(ctx context.Context, dgst string, buf []byte) error {
+	if e.seen[dgst] {
+		return nil
+	}
+	if err := e.writeBytes(ociBlobName(dgst), buf); err != nil {
+		return err
+	}
+	e.seen[dgst] = true
+	return nil
+}
+
+func (e *ociExport) writeBytes(name string, buf []byte) error {
+	hdr := &tar.Header{Typeflag: tar.TypeReg, Name: name, Size: int64(len(buf)), Mode: 0o644, ModTime: e.now}
+	if err := e.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := io.Copy(e.tw, bytes.NewReader(buf)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+func (e *ociExport) writeDir(name string) error {
+	hdr := &tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: 0o755, ModTime: e.now}
+	if err := e.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// splitDigest splits a sha256 digest into its algorithm and hex encoding.
+func splitDigest(dgst string) (string, string, error) {
+	alg, hex, ok := strings.Cut(dgst, ":")
+	if !ok || alg != "sha256" || len(hex) != 64 {
+		return "", "", fmt.Errorf("unsupported digest %q", dgst)
+	}
+	return alg, hex, nil
+}
+
+// registryBlobPath is the path of the blob data in the registry layout.
+func registryBlobPath(dgst string) (string, error) {
+	alg, hex, err := splitDigest(dgst)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(registryRoot, "blobs", alg, hex[:2], hex, "data"), nil
+}
+
+// ociBlobName is the name of the blob in the OCI image layout.
+func ociBlobName(dgst string) string {
+	alg, hex, _ := strings.Cut(dgst, ":")
+	return path.Join("blobs", alg, hex)
+}
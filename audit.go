@@ -0,0 +1,243 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// auditFeed is the feed the head of the audit log is published on. Like the
+// snapshot index it has no leading slash and cannot collide with a path.
+const auditFeed = "swarmdriver/audit"
+
+// ErrAuditLogBroken is returned by VerifyAuditLog when the audit log has been
+// truncated, rewound, misses a mutation that could not be recorded or
+// otherwise does not form an unbroken chain.
+var ErrAuditLogBroken = errors.New("swarmdriver: audit log is broken")
+
+// AuditOp is the operation recorded by an AuditEntry.
+type AuditOp string
+
+const (
+	AuditPutContent AuditOp = "PutContent"
+	AuditCommit     AuditOp = "Commit"
+	AuditMove       AuditOp = "Move"
+	AuditDelete     AuditOp = "Delete"
+	AuditSetModTime AuditOp = "SetModTime"
+	AuditRestore    AuditOp = "RestoreSnapshot"
+)
+
+// AuditEntry is a single record of the audit log. Entries are stored as
+// chunks, each referencing its predecessor, and the newest entry is
+// published on the driver's audit feed.
+type AuditEntry struct {
+	Seq    uint64        `json:"seq"`             // Position in the log, starting at 1.
+	Time   time.Time     `json:"time"`            // Time the mutation completed.
+	Op     AuditOp       `json:"op"`              // Mutating operation.
+	Path   string        `json:"path"`            // Path that was mutated, the source for moves.
+	Dest   string        `json:"dest,omitempty"`  // Destination of a move.
+	OldRef swarm.Address `json:"oldRef"`          // Data reference before the mutation, zero if there was none.
+	NewRef swarm.Address `json:"newRef"`          // Data reference after the mutation, zero if there is none.
+	Actor  string        `json:"actor,omitempty"` // Caller identity set with WithActor.
+	Prev   swarm.Address `json:"prev"`            // Reference of the previous entry, zero for the first.
+}
+
+type actorKey struct{}
+
+// WithActor returns a context that attributes mutations made with it to
+// actor in the audit log.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, if any.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+// auditDataRef returns the current data reference of path for the audit log.
+// It does no work if the audit log is disabled.
+func (d *swarmDriver) auditDataRef(ctx context.Context, path string) (swarm.Address, error) {
+	if !d.auditLog {
+		return swarm.ZeroAddress, nil
+	}
+	ref, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
+	if errors.Is(err, lookuper.ErrNotFound) {
+		return swarm.ZeroAddress, nil
+	}
+	if err != nil {
+		return swarm.ZeroAddress, fmt.Errorf("audit: failed to lookup data reference: %w", err)
+	}
+	return ref, nil
+}
+
+// auditHead returns the reference and contents of the newest audit entry.
+// An empty log yields a zero reference and a zero entry.
+func (d *swarmDriver) auditHead(ctx context.Context) (swarm.Address, AuditEntry, error) {
+	var head AuditEntry
+	ref, err := d.lookuper.Get(ctx, auditFeed, time.Now().Unix())
+	if errors.Is(err, lookuper.ErrNotFound) || (err == nil && isZeroAddress(ref)) {
+		return swarm.ZeroAddress, head, nil
+	}
+	if err != nil {
+		return swarm.ZeroAddress, head, fmt.Errorf("failed to lookup audit log: %w", err)
+	}
+	if err := d.getJSON(ctx, ref, &head); err != nil {
+		return swarm.ZeroAddress, head, fmt.Errorf("failed to read audit entry: %w", err)
+	}
+	return ref, head, nil
+}
+
+// audit appends an entry to the audit log if it is enabled. It must be called
+// with d.mutex held for writing, after the mutation has succeeded. The
+// mutation is live by then, so a failure to append does not fail it: it is
+// logged and recorded, and VerifyAuditLog reports the log as broken.
+func (d *swarmDriver) audit(ctx context.Context, op AuditOp, path, dest string, oldRef, newRef swarm.Address) {
+	if !d.auditLog {
+		return
+	}
+	if err := d.appendAudit(ctx, op, path, dest, oldRef, newRef); err != nil {
+		logger.Error("audit: Failed to append entry", slog.String("op", string(op)), slog.String("path", path), slog.String("error", err.Error()))
+		if d.auditErr == nil {
+			d.auditErr = err
+		}
+	}
+}
+
+// appendAudit stores an entry for the mutation and publishes it as the new
+// head of the audit log.
+func (d *swarmDriver) appendAudit(ctx context.Context, op AuditOp, path, dest string, oldRef, newRef swarm.Address) error {
+	prev, head, err := d.auditHead(ctx)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	entry := AuditEntry{
+		Seq:    head.Seq + 1,
+		Time:   time.Now().UTC(),
+		Op:     op,
+		Path:   path,
+		Dest:   dest,
+		OldRef: oldRef,
+		NewRef: newRef,
+		Prev:   prev,
+	}
+	entry.Actor, _ = ActorFromContext(ctx)
+	ref, err := d.putJSON(ctx, entry)
+	if err != nil {
+		return fmt.Errorf("audit: failed to store entry: %w", err)
+	}
+	if err := d.publisher.Put(ctx, auditFeed, time.Now().Unix(), ref); err != nil {
+		return fmt.Errorf("audit: failed to publish entry: %w", err)
+	}
+	return nil
+}
+
+// AuditLog returns the entries of the audit log, oldest first. A chain that
+// does not count down to the first entry fails with an error wrapping
+// ErrAuditLogBroken.
+func (d *swarmDriver) AuditLog(ctx context.Context) ([]AuditEntry, error) {
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	logger.Debug("AuditLog Hit")
+	ref, head, err := d.auditHead(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("AuditLog: %w", err)
+	}
+	if ref.IsZero() {
+		return []AuditEntry{}, nil
+	}
+	if head.Seq == 0 {
+		return nil, fmt.Errorf("AuditLog: %w: head entry has sequence 0", ErrAuditLogBroken)
+	}
+	// The sequence is only trusted as far as the chain confirms it, so the
+	// entries are collected newest first rather than allocated up front.
+	var entries []AuditEntry
+	entry := head
+	for seq := head.Seq; ; seq-- {
+		if entry.Seq != seq {
+			return nil, fmt.Errorf("AuditLog: %w: want sequence %d, got %d", ErrAuditLogBroken, seq, entry.Seq)
+		}
+		entries = append(entries, entry)
+		if seq == 1 {
+			break
+		}
+		if entry.Prev.IsZero() {
+			return nil, fmt.Errorf("AuditLog: %w: entry %d has no predecessor", ErrAuditLogBroken, seq)
+		}
+		prev := entry.Prev
+		entry = AuditEntry{}
+		if err := d.getJSON(ctx, prev, &entry); err != nil {
+			return nil, fmt.Errorf("AuditLog: failed to read entry %d: %w", seq-1, err)
+		}
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// VerifyAuditLog checks that the audit log is intact: every entry links to
+// its predecessor with consecutive sequence numbers down to the first, and
+// the audit feed holds exactly one update per entry, each pointing at the
+// entry with the matching sequence number. Truncating the chain or rewinding
+// the feed to an older entry fails with an error wrapping ErrAuditLogBroken,
+// as does a mutation this driver applied but failed to record.
+func (d *swarmDriver) VerifyAuditLog(ctx context.Context) error {
+	if err := d.acquire(); err != nil {
+		return err
+	}
+	defer d.release()
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	logger.Debug("VerifyAuditLog Hit")
+	if d.auditErr != nil {
+		return fmt.Errorf("VerifyAuditLog: %w: a mutation was not recorded: %v", ErrAuditLogBroken, d.auditErr)
+	}
+	updates, err := lookuper.History(ctx, d.store, d.owner, auditFeed)
+	if err != nil {
+		return fmt.Errorf("VerifyAuditLog: %w", err)
+	}
+	ref, head, err := d.auditHead(ctx)
+	if err != nil {
+		return fmt.Errorf("VerifyAuditLog: %w", err)
+	}
+	if uint64(len(updates)) != head.Seq {
+		return fmt.Errorf("VerifyAuditLog: %w: feed has %d updates, head entry has sequence %d", ErrAuditLogBroken, len(updates), head.Seq)
+	}
+	entry := head
+	for seq := head.Seq; seq > 0; seq-- {
+		if entry.Seq != seq {
+			return fmt.Errorf("VerifyAuditLog: %w: want sequence %d, got %d", ErrAuditLogBroken, seq, entry.Seq)
+		}
+		if !updates[seq-1].Reference.Equal(ref) {
+			return fmt.Errorf("VerifyAuditLog: %w: feed update %d does not reference entry %d", ErrAuditLogBroken, seq-1, seq)
+		}
+		if seq == 1 {
+			if !entry.Prev.IsZero() {
+				return fmt.Errorf("VerifyAuditLog: %w: first entry has a predecessor", ErrAuditLogBroken)
+			}
+			break
+		}
+		if entry.Prev.IsZero() {
+			return fmt.Errorf("VerifyAuditLog: %w: entry %d has no predecessor", ErrAuditLogBroken, seq)
+		}
+		ref = entry.Prev
+		entry = AuditEntry{}
+		if err := d.getJSON(ctx, ref, &entry); err != nil {
+			return fmt.Errorf("VerifyAuditLog: failed to read entry %d: %w", seq-1, err)
+		}
+	}
+	logger.Debug("VerifyAuditLog: Success!", slog.Uint64("entries", head.Seq))
+	return nil
+}
@@ -0,0 +1,143 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestAuditLog(t *testing.T) {
+	ctx := WithActor(context.Background(), "alice")
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/a", []byte("a1")); err != nil {
+		t.Fatal(err)
+	}
+	w, err := d.Writer(ctx, "/repo/b", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("b1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Move(ctx, "/repo/b", "/repo/c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(context.Background(), "/repo/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := d.AuditLog(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AuditOp{AuditPutContent, AuditCommit, AuditMove, AuditDelete}
+	if len(entries) != len(want) {
+		t.Fatalf("want %d entries, got %+v", len(want), entries)
+	}
+	for i, e := range entries {
+		if e.Op != want[i] || e.Seq != uint64(i+1) {
+			t.Fatalf("entry %d: want %s with sequence %d, got %+v", i, want[i], i+1, e)
+		}
+	}
+	if entries[0].Actor != "alice" || entries[3].Actor != "" {
+		t.Fatalf("unexpected actors %q and %q", entries[0].Actor, entries[3].Actor)
+	}
+	if entries[2].Dest != "/repo/c" || entries[3].OldRef.IsZero() || !entries[3].NewRef.IsZero() {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if err := d.VerifyAuditLog(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewind the feed to an older entry.
+	if err := d.publisher.Put(ctx, auditFeed, time.Now().Unix(), entries[3].Prev); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.VerifyAuditLog(ctx); !errors.Is(err, ErrAuditLogBroken) {
+		t.Fatalf("want ErrAuditLogBroken, got %v", err)
+	}
+}
+
+func TestAuditLogForgedHead(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	for _, head := range []AuditEntry{
+		{Seq: 0, Op: AuditPutContent},
+		{Seq: 1 << 40, Op: AuditPutContent},
+	} {
+		ref, err := d.putJSON(ctx, head)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.publisher.Put(ctx, auditFeed, time.Now().Unix(), ref); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.AuditLog(ctx); !errors.Is(err, ErrAuditLogBroken) {
+			t.Fatalf("sequence %d: want ErrAuditLogBroken, got %v", head.Seq, err)
+		}
+	}
+
+	// Mutations are not recorded once the audit log is disabled.
+	d, err = New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false, WithoutAuditLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err := d.AuditLog(ctx); err != nil || len(entries) != 0 {
+		t.Fatalf("want no entries, got %+v, %v", entries, err)
+	}
+}
+
+// failingPublisher fails to publish the feed id.
+type failingPublisher struct {
+	Publisher
+	id string
+}
+
+func (p failingPublisher) Put(ctx context.Context, id string, version int64, ref swarm.Address) error {
+	if id == p.id {
+		return errors.New("publish failed")
+	}
+	return p.Publisher.Put(ctx, id, version, ref)
+}
+
+func TestAuditLogAppendFailure(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := d.publisher
+	d.publisher = failingPublisher{Publisher: publisher, id: auditFeed}
+	// The write is live even though it could not be recorded.
+	if err := d.PutContent(ctx, "/a", []byte("a")); err != nil {
+		t.Fatalf("want the applied write to succeed, got %v", err)
+	}
+	if got, err := d.GetContent(ctx, "/a"); err != nil || string(got) != "a" {
+		t.Fatalf("want a, got %q, %v", got, err)
+	}
+	d.publisher = publisher
+	if err := d.VerifyAuditLog(ctx); !errors.Is(err, ErrAuditLogBroken) {
+		t.Fatalf("want ErrAuditLogBroken, got %v", err)
+	}
+}
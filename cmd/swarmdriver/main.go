@@ -0,0 +1,555 @@
+// Command swarmdriver inspects and edits a swarm-backed registry tree.
+//
+// Usage:
+//
+//	swarmdriver -store DIR (-keyfile FILE | -owner ADDRESS) [-json] COMMAND [ARGS]
+//
+// The tree is read from the chunk store in DIR. With -keyfile the tree owned
+// by that key is opened for reading and writing, with -owner the tree of
+// ADDRESS is followed read-only. Commands that only read follow the tree of
+// the key too, so they publish no feed updates. Commands:
+//
+//	ls PATH         list the children of a directory
+//	stat PATH       show size, modification time and kind of a path
+//	cat PATH        write the content of a file to stdout
+//	put PATH [FILE] store FILE, or stdin, at PATH
+//	rm PATH         unlink a path and everything below it from the tree; the
+//	                chunks stay in the store until gc deletes them
+//	mv SRC DST      move a path
+//	tree [PATH]     list every path below PATH, "/" by default
+//	history PATH    list the updates of the feeds of a path
+//	ref PATH        print the swarm references behind a path
+//	feed ID         dump the decoded updates of the raw feed ID
+//	fsck [-repair] [-fast]
+//	                check the tree for inconsistencies, fixing what is safe;
+//	                -fast checks that chunks are stored without reading them
+//	recover PATH... relink paths lost from their directories
+//	gc [-dry-run] [-grace DURATION]
+//	                delete chunks the tree no longer references
+//	quota [-recompute] [PREFIX BYTES]
+//	                list quotas, set the quota of PREFIX (0 removes it) or
+//	                recompute their usage from the tree
+//	stats [PREFIX]  show the logical and physical usage below PREFIX
+//
+// With -json every command except cat prints JSON.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver"
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/filestore"
+)
+
+// errUsage reports a malformed command line.
+var errUsage = errors.New("usage")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// driver is the part of the swarm driver the commands use.
+type driver interface {
+	storagedriver.StorageDriver
+	History(ctx context.Context, path string) ([]swarmdriver.Version, error)
+	Owner() common.Address
+	Fsck(ctx context.Context, opts swarmdriver.FsckOptions) (swarmdriver.FsckReport, error)
+	RecoverPaths(ctx context.Context, paths []string) (swarmdriver.RecoverResult, error)
+	GC(ctx context.Context, opts swarmdriver.GCOptions) (swarmdriver.GCReport, error)
+	Quotas(ctx context.Context) ([]swarmdriver.Quota, error)
+	SetQuota(ctx context.Context, prefix string, limit int64) (swarmdriver.Quota, error)
+	RecomputeQuotas(ctx context.Context) ([]swarmdriver.Quota, error)
+	Stats(ctx context.Context, prefix string) (swarmdriver.Stats, error)
+	SwarmStat(ctx context.Context, path string) (swarmdriver.FileInfo, error)
+	Close() error
+}
+
+// cli executes commands against an opened tree.
+type cli struct {
+	d      driver
+	store  store.PutGetter
+	json   bool
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("swarmdriver", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	storeDir := flags.String("store", "", "directory of the chunk store")
+	keyFile := flags.String("keyfile", "", "file holding the hex encoded private key owning the tree")
+	owner := flags.String("owner", "", "address of the owner of a tree to follow read-only")
+	encrypt := flags.Bool("encrypt", false, "encrypt content that is written")
+	jsonOutput := flags.Bool("json", false, "print JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *storeDir == "" || (*keyFile == "") == (*owner == "") || flags.NArg() == 0 {
+		fmt.Fprintln(stderr, "swarmdriver: -store, one of -keyfile and -owner, and a command are required")
+		flags.Usage()
+		return 2
+	}
+	// Keep the driver's debug records out of the command output.
+	swarmdriver.SetLogger(slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	chunkStore, err := filestore.New(*storeDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "swarmdriver: %v\n", err)
+		return 1
+	}
+	c := &cli{store: chunkStore, json: *jsonOutput, stdin: stdin, stdout: stdout}
+	if *keyFile != "" {
+		buf, err := os.ReadFile(*keyFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "swarmdriver: failed to read key: %v\n", err)
+			return 1
+		}
+		signer, err := swarmdriver.SignerFromHex(string(buf))
+		if err != nil {
+			fmt.Fprintf(stderr, "swarmdriver: failed to decode key: %v\n", err)
+			return 1
+		}
+		if readOnlyCommands[flags.Arg(0)] {
+			// Opening the tree for writing publishes its root.
+			addr, err := signer.EthereumAddress()
+			if err != nil {
+				fmt.Fprintf(stderr, "swarmdriver: failed to derive key address: %v\n", err)
+				return 1
+			}
+			c.d, err = swarmdriver.NewFollower(ctx, addr, chunkStore)
+		} else {
+			c.d, err = swarmdriver.New(ctx, common.Address{}, chunkStore, *encrypt, swarmdriver.WithSigner(signer))
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "swarmdriver: %v\n", err)
+			return 1
+		}
+	} else {
+		if !common.IsHexAddress(*owner) {
+			fmt.Fprintf(stderr, "swarmdriver: invalid owner %q\n", *owner)
+			return 2
+		}
+		c.d, err = swarmdriver.NewFollower(ctx, common.HexToAddress(*owner), chunkStore)
+		if err != nil {
+			fmt.Fprintf(stderr, "swarmdriver: %v\n", err)
+			return 1
+		}
+	}
+	defer c.d.Close()
+	if err := c.execute(ctx, flags.Arg(0), flags.Args()[1:]); err != nil {
+		fmt.Fprintf(stderr, "swarmdriver: %s: %v\n", flags.Arg(0), err)
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// readOnlyCommands are the commands that never write to the tree.
+var readOnlyCommands = map[string]bool{
+	"ls": true, "stat": true, "cat": true, "tree": true, "history": true,
+	"ref": true, "feed": true, "stats": true,
+}
+
+// execute runs the command cmd with args.
+func (c *cli) execute(ctx context.Context, cmd string, args []string) error {
+	want := map[string]int{
+		"ls": 1, "stat": 1, "cat": 1, "put": -1, "rm": 1, "mv": 2,
+		"tree": -1, "history": 1, "ref": 1, "feed": 1, "fsck": -1,
+		"recover": -1, "gc": -1, "quota": -1, "stats": -1,
+	}
+	n, ok := want[cmd]
+	switch {
+	case !ok:
+		return fmt.Errorf("%w: unknown command", errUsage)
+	case n >= 0 && len(args) != n:
+		return fmt.Errorf("%w: want %d arguments, got %d", errUsage, n, len(args))
+	}
+	switch cmd {
+	case "ls":
+		return c.ls(ctx, args[0])
+	case "stat":
+		return c.stat(ctx, args[0])
+	case "cat":
+		return c.cat(ctx, args[0])
+	case "put":
+		return c.put(ctx, args)
+	case "rm":
+		return c.d.Delete(ctx, args[0])
+	case "mv":
+		return c.d.Move(ctx, args[0], args[1])
+	case "tree":
+		return c.tree(ctx, args)
+	case "history":
+		return c.history(ctx, args[0])
+	case "ref":
+		return c.ref(ctx, args[0])
+	case "fsck":
+		return c.fsck(ctx, args)
+	case "recover":
+		return c.recover(ctx, args)
+	case "gc":
+		return c.gc(ctx, args)
+	case "quota":
+		return c.quota(ctx, args)
+	case "stats":
+		return c.stats(ctx, args)
+	}
+	return c.feed(ctx, args[0])
+}
+
+// fileInfo is the printed form of a storagedriver.FileInfo.
+type fileInfo struct {
+	Path    string            `json:"path"`
+	Size    int64             `json:"size"`
+	ModTime time.Time         `json:"modTime"`
+	IsDir   bool              `json:"isDir"`
+	Digests map[string]string `json:"digests,omitempty"`
+	// Swarm references of the path, when stat'ed through a swarm driver.
+	MetaRef   string    `json:"metaRef,omitempty"`
+	DataRef   string    `json:"dataRef,omitempty"`
+	MetaIndex uint64    `json:"metaIndex,omitempty"`
+	DataIndex uint64    `json:"dataIndex,omitempty"`
+	Updated   time.Time `json:"updated,omitempty"`
+	Encrypted bool      `json:"encrypted,omitempty"`
+}
+
+func newFileInfo(fi storagedriver.FileInfo) fileInfo {
+	info := fileInfo{Path: fi.Path(), Size: fi.Size(), ModTime: fi.ModTime().UTC(), IsDir: fi.IsDir()}
+	if sfi, ok := fi.(swarmdriver.FileInfo); ok {
+		info.Digests = sfi.Digests
+		info.MetaRef = sfi.MetaRef.String()
+		if !sfi.DataRef.IsZero() {
+			info.DataRef = sfi.DataRef.String()
+		}
+		info.MetaIndex, info.DataIndex = sfi.MetaIndex, sfi.DataIndex
+		info.Updated = sfi.Updated.UTC()
+		info.Encrypted = sfi.Encrypted
+	}
+	return info
+}
+
+func (fi fileInfo) String() string {
+	kind := "file"
+	if fi.IsDir {
+		kind = "dir"
+	}
+	return fmt.Sprintf("%s\t%d\t%s\t%s", kind, fi.Size, fi.ModTime.Format(time.RFC3339), fi.Path)
+}
+
+// print writes v as JSON, or each line produced by text otherwise.
+func (c *cli) print(v interface{}, text func(w io.Writer)) error {
+	if c.json {
+		enc := json.NewEncoder(c.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	text(tw)
+	return tw.Flush()
+}
+
+func (c *cli) ls(ctx context.Context, path string) error {
+	children, err := c.d.List(ctx, path)
+	if err != nil {
+		return err
+	}
+	return c.print(children, func(w io.Writer) {
+		for _, child := range children {
+			fmt.Fprintln(w, child)
+		}
+	})
+}
+
+func (c *cli) stat(ctx context.Context, path string) error {
+	fi, err := c.d.SwarmStat(ctx, path)
+	if err != nil {
+		return err
+	}
+	info := newFileInfo(fi)
+	return c.print(info, func(w io.Writer) {
+		fmt.Fprintln(w, info)
+	})
+}
+
+func (c *cli) cat(ctx context.Context, path string) error {
+	r, err := c.d.Reader(ctx, path, 0)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(c.stdout, r)
+	return err
+}
+
+func (c *cli) put(ctx context.Context, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return fmt.Errorf("%w: want PATH [FILE]", errUsage)
+	}
+	src := c.stdin
+	if len(args) == 2 {
+		f, err := os.Open(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		src = f
+	}
+	w, err := c.d.Writer(ctx, args[0], false)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		w.Cancel(ctx)
+		return err
+	}
+	if err := w.Commit(ctx); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+func (c *cli) tree(ctx context.Context, args []string) error {
+	root := "/"
+	switch len(args) {
+	case 0:
+	case 1:
+		root = args[0]
+	default:
+		return fmt.Errorf("%w: want [PATH]", errUsage)
+	}
+	infos := []fileInfo{}
+	err := c.d.Walk(ctx, root, func(fi storagedriver.FileInfo) error {
+		infos = append(infos, newFileInfo(fi))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(infos, func(w io.Writer) {
+		for _, info := range infos {
+			fmt.Fprintln(w, info)
+		}
+	})
+}
+
+func (c *cli) history(ctx context.Context, path string) error {
+	versions, err := c.d.History(ctx, path)
+	if err != nil {
+		return err
+	}
+	return c.print(versions, func(w io.Writer) {
+		for _, v := range versions {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", v.Feed, v.Index, v.Time.UTC().Format(time.RFC3339), v.Reference)
+		}
+	})
+}
+
+// references are the feed references behind a path.
+type references struct {
+	Path string        `json:"path"`
+	Meta swarm.Address `json:"meta"`
+	Data swarm.Address `json:"data,omitempty"`
+}
+
+func (c *cli) ref(ctx context.Context, path string) error {
+	lk := lookuper.New(c.store, c.d.Owner())
+	now := time.Now().Unix()
+	refs := references{Path: path}
+	var err error
+	refs.Meta, err = lk.Get(ctx, filepath.Join(path, "mtdt"), now)
+	if err != nil {
+		return err
+	}
+	refs.Data, err = lk.Get(ctx, filepath.Join(path, "data"), now)
+	if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+		return err
+	}
+	return c.print(refs, func(w io.Writer) {
+		fmt.Fprintf(w, "mtdt\t%s\n", refs.Meta)
+		if !refs.Data.IsZero() {
+			fmt.Fprintf(w, "data\t%s\n", refs.Data)
+		}
+	})
+}
+
+// update is the printed form of a lookuper.Update.
+type update struct {
+	Index     uint64        `json:"index"`
+	Timestamp time.Time     `json:"timestamp"`
+	Reference swarm.Address `json:"reference"`
+}
+
+func (c *cli) feed(ctx context.Context, id string) error {
+	updates, err := lookuper.History(ctx, c.store, c.d.Owner(), id)
+	if err != nil {
+		return err
+	}
+	out := make([]update, 0, len(updates))
+	for _, u := range updates {
+		out = append(out, update{Index: u.Index, Timestamp: time.Unix(u.Timestamp, 0).UTC(), Reference: u.Reference})
+	}
+	return c.print(out, func(w io.Writer) {
+		for _, u := range out {
+			ref := u.Reference.String()
+			if u.Reference.IsZero() {
+				ref = "(deleted)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", u.Index, u.Timestamp.Format(time.RFC3339), ref)
+		}
+	})
+}
+
+// errInconsistent reports that fsck found problems that remain.
+var errInconsistent = errors.New("tree is inconsistent")
+
+func (c *cli) fsck(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	repair := flags.Bool("repair", false, "fix what is safely fixable")
+	fast := flags.Bool("fast", false, "check that chunks are stored without reading them")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return fmt.Errorf("%w: want [-repair] [-fast]", errUsage)
+	}
+	report, err := c.d.Fsck(ctx, swarmdriver.FsckOptions{Repair: *repair, Fast: *fast})
+	if err != nil {
+		return err
+	}
+	if err := c.print(report, func(w io.Writer) {
+		for _, issue := range report.Issues {
+			state := "found"
+			if issue.Repaired {
+				state = "repaired"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", state, issue.Kind, issue.Path, issue.Detail)
+		}
+		fmt.Fprintf(w, "checked %d directories, %d files, %d bytes\n", report.Dirs, report.Files, report.Bytes)
+	}); err != nil {
+		return err
+	}
+	for _, issue := range report.Issues {
+		if !issue.Repaired {
+			return errInconsistent
+		}
+	}
+	return nil
+}
+
+func (c *cli) recover(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("%w: want at least one path", errUsage)
+	}
+	result, err := c.d.RecoverPaths(ctx, paths)
+	if err != nil {
+		return err
+	}
+	return c.print(result, func(w io.Writer) {
+		for _, p := range result.Rebuilt {
+			fmt.Fprintf(w, "rebuilt\t%s\n", p)
+		}
+		for _, p := range result.Linked {
+			fmt.Fprintf(w, "linked\t%s\n", p)
+		}
+		for _, p := range result.Missing {
+			fmt.Fprintf(w, "missing\t%s\n", p)
+		}
+	})
+}
+
+func (c *cli) gc(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	dryRun := flags.Bool("dry-run", false, "report what would be deleted")
+	grace := flags.Duration("grace", swarmdriver.DefaultGCGrace, "keep chunks stored more recently")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return fmt.Errorf("%w: want [-dry-run] [-grace DURATION]", errUsage)
+	}
+	report, err := c.d.GC(ctx, swarmdriver.GCOptions{DryRun: *dryRun, Grace: *grace})
+	if err != nil {
+		return err
+	}
+	return c.print(report, func(w io.Writer) {
+		verb := "deleted"
+		if *dryRun {
+			verb = "would delete"
+		}
+		fmt.Fprintf(w, "scanned %d chunks, %d reachable, %d recent\n", report.Scanned, report.Reachable, report.Recent)
+		fmt.Fprintf(w, "%s %d chunks (%d bytes)\n", verb, report.Swept, report.SweptBytes)
+	})
+}
+
+func (c *cli) quota(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("quota", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	recompute := flags.Bool("recompute", false, "recompute the usage of every quota")
+	if err := flags.Parse(args); err != nil || (flags.NArg() != 0 && flags.NArg() != 2) {
+		return fmt.Errorf("%w: want [-recompute] [PREFIX BYTES]", errUsage)
+	}
+	if flags.NArg() == 2 {
+		limit, err := strconv.ParseInt(flags.Arg(1), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid limit %q", errUsage, flags.Arg(1))
+		}
+		if _, err := c.d.SetQuota(ctx, flags.Arg(0), limit); err != nil {
+			return err
+		}
+	}
+	var quotas []swarmdriver.Quota
+	var err error
+	if *recompute {
+		quotas, err = c.d.RecomputeQuotas(ctx)
+	} else {
+		quotas, err = c.d.Quotas(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	return c.print(quotas, func(w io.Writer) {
+		for _, q := range quotas {
+			fmt.Fprintf(w, "%d\t%d\t%s\n", q.Usage, q.Limit, q.Prefix)
+		}
+	})
+}
+
+func (c *cli) stats(ctx context.Context, args []string) error {
+	prefix := "/"
+	switch len(args) {
+	case 0:
+	case 1:
+		prefix = args[0]
+	default:
+		return fmt.Errorf("%w: want [PREFIX]", errUsage)
+	}
+	stats, err := c.d.Stats(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	return c.print(stats, func(w io.Writer) {
+		for _, child := range stats.Children {
+			fmt.Fprintf(w, "%d\t%d files\t%s\n", child.LogicalBytes, child.Files, child.Prefix)
+		}
+		fmt.Fprintf(w, "%d\t%d files\t%s\n", stats.LogicalBytes, stats.Files, stats.Prefix)
+		fmt.Fprintf(w, "%d references in %d chunks of %d bytes, dedup ratio %.2f\n",
+			len(stats.Refs), stats.PhysicalChunks, stats.PhysicalBytes, stats.DedupRatio)
+	})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+
+	"github.com/Raviraj2000/swarmdriver"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	pk, err := beecrypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := beecrypto.EncodeSecp256k1PrivateKey(pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(hex.EncodeToString(raw)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	owner, err := beecrypto.NewDefaultSigner(pk).EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storeDir := filepath.Join(dir, "chunks")
+	swarm := func(stdin string, args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-store", storeDir, "-keyfile", keyFile}, args...)
+		if code := run(ctx, args, strings.NewReader(stdin), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit code %d: %s", args, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	swarm("manifest", "put", "/repo/manifest")
+	swarm("", "mv", "/repo/manifest", "/repo/tag")
+	if got := swarm("", "cat", "/repo/tag"); got != "manifest" {
+		t.Fatalf("cat: want manifest, got %q", got)
+	}
+	if got := swarm("", "ls", "/repo"); !strings.Contains(got, "/repo/tag") {
+		t.Fatalf("ls: got %q", got)
+	}
+	var info fileInfo
+	if err := json.Unmarshal([]byte(swarm("", "-json", "stat", "/repo/tag")), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != int64(len("manifest")) || info.IsDir || len(info.Digests["sha256"]) != 64 || len(info.DataRef) != 64 || info.Encrypted {
+		t.Fatalf("stat: unexpected %+v", info)
+	}
+	var refs map[string]string
+	if err := json.Unmarshal([]byte(swarm("", "-json", "ref", "/repo/tag")), &refs); err != nil {
+		t.Fatal(err)
+	}
+	if refs["meta"] == "" || refs["data"] == "" {
+		t.Fatalf("ref: unexpected %v", refs)
+	}
+	if got := swarm("", "feed", "/repo/tag/data"); !strings.Contains(got, refs["data"]) {
+		t.Fatalf("feed: want %s in %q", refs["data"], got)
+	}
+	if got := swarm("", "history", "/repo/tag"); !strings.Contains(got, "mtdt") {
+		t.Fatalf("history: got %q", got)
+	}
+	if got := swarm("", "fsck"); !strings.Contains(got, "1 files") {
+		t.Fatalf("fsck: got %q", got)
+	}
+	if got := swarm("", "gc", "-dry-run"); !strings.Contains(got, "would delete 0 chunks") {
+		t.Fatalf("gc: got %q", got)
+	}
+	if got := swarm("", "recover", "/repo/tag", "/nothing"); strings.Join(strings.Fields(got), " ") != "missing /nothing" {
+		t.Fatalf("recover: got %q", got)
+	}
+	if got := swarm("", "quota", "/repo", "100"); strings.Join(strings.Fields(got), " ") != "8 100 /repo" {
+		t.Fatalf("quota: got %q", got)
+	}
+	if got := swarm("", "stats", "/repo"); !strings.Contains(got, "1 references in 1 chunks") {
+		t.Fatalf("stats: got %q", got)
+	}
+	// Commands that only read publish nothing.
+	before := countFiles(t, storeDir)
+	swarm("", "ls", "/repo")
+	swarm("", "stat", "/repo/tag")
+	swarm("", "tree")
+	if after := countFiles(t, storeDir); after != before {
+		t.Fatalf("reading commands stored %d chunks", after-before)
+	}
+	swarm("", "rm", "/repo/tag")
+
+	// The tree can be followed without the key.
+	var stdout, stderr bytes.Buffer
+	args := []string{"-store", storeDir, "-owner", owner.Hex(), "-json", "tree"}
+	if code := run(ctx, args, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("tree: exit code %d: %s", code, stderr.String())
+	}
+	var infos []fileInfo
+	if err := json.Unmarshal(stdout.Bytes(), &infos); err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Path != "/repo" {
+		t.Fatalf("tree: want only /repo, got %+v", infos)
+	}
+	args = []string{"-store", storeDir, "-owner", owner.Hex(), "rm", "/repo"}
+	if code := run(ctx, args, nil, &stdout, &stderr); code != 1 {
+		t.Fatalf("rm on a follower: want exit code 1, got %d", code)
+	}
+}
+
+func TestExecuteInMemory(t *testing.T) {
+	ctx := context.Background()
+	chunkStore := teststore.NewSwarmInMemoryStore()
+	d, err := swarmdriver.New(ctx, common.HexToAddress("0xabcd"), chunkStore, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	c := &cli{d: d, store: chunkStore, stdin: strings.NewReader("blob"), stdout: &stdout}
+	if err := c.execute(ctx, "put", []string{"/a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.execute(ctx, "cat", []string{"/a"}); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "blob" {
+		t.Fatalf("want blob, got %q", stdout.String())
+	}
+	if err := c.execute(ctx, "mv", []string{"/a"}); err == nil {
+		t.Fatal("want usage error")
+	}
+}
+
+// countFiles returns the number of files below dir.
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,149 @@
+// Command swarmmigrate copies a registry from another storage driver into a
+// swarm driver backed by an on-disk chunk store.
+//
+// Usage:
+//
+//	swarmmigrate -store DIR -keyfile FILE [-from NAME] [-param KEY=VALUE]...
+//	    [-checkpoint FILE] [-workers N] [-encrypt]
+//
+// The source driver is created through the distribution driver factory, for
+// example -from filesystem -param rootdirectory=/var/lib/registry or
+// -from s3 -param region=us-east-1 -param bucket=registry. Drivers other than
+// filesystem and s3 are made available by importing them below. The
+// feeds are owned by the key in -keyfile, which is generated if the file does
+// not exist; its address is printed so that followers can read the tree.
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/distribution/distribution/v3/registry/storage/driver/factory"
+	_ "github.com/distribution/distribution/v3/registry/storage/driver/filesystem"
+	_ "github.com/distribution/distribution/v3/registry/storage/driver/s3-aws"
+	"github.com/ethereum/go-ethereum/common"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+
+	"github.com/Raviraj2000/swarmdriver"
+	"github.com/Raviraj2000/swarmdriver/migrate"
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/filestore"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// params collects repeated KEY=VALUE flags.
+type params map[string]interface{}
+
+func (p params) String() string {
+	return fmt.Sprint(map[string]interface{}(p))
+}
+
+func (p params) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("want KEY=VALUE, got %q", s)
+	}
+	p[key] = value
+	return nil
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("swarmmigrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	from := flags.String("from", "filesystem", "name of the source storage driver")
+	sourceParams := params{}
+	flags.Var(sourceParams, "param", "source driver parameter as KEY=VALUE, may be repeated")
+	storeDir := flags.String("store", "", "directory of the target chunk store")
+	keyFile := flags.String("keyfile", "", "file holding the hex encoded private key owning the feeds, generated if missing")
+	checkpoint := flags.String("checkpoint", "", "checkpoint file to resume an interrupted migration")
+	workers := flags.Int("workers", 4, "number of files copied in parallel")
+	encrypt := flags.Bool("encrypt", false, "encrypt content in the target")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *storeDir == "" || *keyFile == "" {
+		fmt.Fprintln(stderr, "swarmmigrate: -store and -keyfile are required")
+		flags.Usage()
+		return 2
+	}
+	if err := migrateRegistry(ctx, *from, sourceParams, *storeDir, *keyFile, *checkpoint, *workers, *encrypt, stdout); err != nil {
+		fmt.Fprintf(stderr, "swarmmigrate: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func migrateRegistry(ctx context.Context, from string, sourceParams params, storeDir, keyFile, checkpoint string, workers int, encrypt bool, stdout io.Writer) error {
+	src, err := factory.Create(ctx, from, sourceParams)
+	if err != nil {
+		return fmt.Errorf("failed to create source driver: %w", err)
+	}
+	signer, err := loadSigner(keyFile)
+	if err != nil {
+		return err
+	}
+	fileStore, err := filestore.New(storeDir)
+	if err != nil {
+		return err
+	}
+	dst, err := swarmdriver.New(ctx, common.Address{}, store.NewValidatingStore(fileStore), encrypt, swarmdriver.WithSigner(signer))
+	if err != nil {
+		return fmt.Errorf("failed to create swarm driver: %w", err)
+	}
+	defer dst.Close()
+	fmt.Fprintf(stdout, "owner %s\n", dst.Owner().Hex())
+	var mu sync.Mutex // Progress is called from several workers.
+	result, err := migrate.Run(ctx, src, dst, migrate.Options{
+		Workers:    workers,
+		Checkpoint: checkpoint,
+		Progress: func(rec migrate.Record) {
+			mu.Lock()
+			defer mu.Unlock()
+			fmt.Fprintf(stdout, "copied %s %d %s\n", rec.Path, rec.Size, rec.Digest)
+		},
+	})
+	fmt.Fprintf(stdout, "copied %d files (%d bytes), skipped %d\n", result.Copied, result.Bytes, result.Skipped)
+	return err
+}
+
+// loadSigner reads the private key in path, generating and saving a new one
+// if the file does not exist.
+func loadSigner(path string) (beecrypto.Signer, error) {
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		pk, err := beecrypto.GenerateSecp256k1Key()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		raw, err := beecrypto.EncodeSecp256k1PrivateKey(pk)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode key: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(hex.EncodeToString(raw)+"\n"), 0o600); err != nil {
+			return nil, fmt.Errorf("failed to save key: %w", err)
+		}
+		return beecrypto.NewDefaultSigner(pk), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	signer, err := swarmdriver.SignerFromHex(string(buf))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+	return signer, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/distribution/distribution/v3/registry/storage/driver/factory"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver"
+	"github.com/Raviraj2000/swarmdriver/store/filestore"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	blob := filepath.Join(root, "docker/registry/v2/blobs/sha256/ab/abcd/data")
+	if err := os.MkdirAll(filepath.Dir(blob), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blob, []byte("layer"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	storeDir := filepath.Join(dir, "chunks")
+	keyFile := filepath.Join(dir, "key")
+	args := []string{
+		"-param", "rootdirectory=" + root,
+		"-store", storeDir,
+		"-keyfile", keyFile,
+		"-checkpoint", filepath.Join(dir, "checkpoint"),
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run(ctx, args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "copied 1 files") {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+
+	// The tree is reopened from the store with the saved key.
+	buf, err := os.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := swarmdriver.SignerFromHex(string(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunkStore, err := filestore.New(storeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := swarmdriver.New(ctx, common.Address{}, chunkStore, false, swarmdriver.WithSigner(signer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := d.GetContent(ctx, "/docker/registry/v2/blobs/sha256/ab/abcd/data"); err != nil || string(content) != "layer" {
+		t.Fatalf("want layer, got %q, %v", content, err)
+	}
+
+	// Rerunning skips what the checkpoint recorded.
+	stdout.Reset()
+	if code := run(ctx, args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "skipped 1") {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestDriversRegistered(t *testing.T) {
+	for _, name := range []string{"filesystem", "s3"} {
+		// Missing parameters are reported by the driver, not the factory.
+		_, err := factory.Create(context.Background(), name, map[string]interface{}{"rootdirectory": 1})
+		var unknown factory.InvalidStorageDriverError
+		if errors.As(err, &unknown) {
+			t.Fatalf("%s: driver not registered", name)
+		}
+	}
+}
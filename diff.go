@@ -0,0 +1,211 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// TreeVersion selects a version of the tree: the live tree, the tree at a
+// point in time or a named snapshot.
+type TreeVersion struct {
+	Time     time.Time // Point in time, ignored if Snapshot is set.
+	Snapshot string    // Name of a snapshot.
+}
+
+// LiveVersion selects the current tree.
+func LiveVersion() TreeVersion {
+	return TreeVersion{}
+}
+
+// TimeVersion selects the tree as it was at t.
+func TimeVersion(t time.Time) TreeVersion {
+	return TreeVersion{Time: t}
+}
+
+// SnapshotVersion selects the tree recorded in the snapshot called name.
+func SnapshotVersion(name string) TreeVersion {
+	return TreeVersion{Snapshot: name}
+}
+
+func (v TreeVersion) String() string {
+	switch {
+	case v.Snapshot != "":
+		return "snapshot " + v.Snapshot
+	case !v.Time.IsZero():
+		return v.Time.UTC().Format(time.RFC3339)
+	}
+	return "live"
+}
+
+// version returns a driver reading the tree selected by v.
+func (d *swarmDriver) version(ctx context.Context, v TreeVersion) (*swarmDriver, error) {
+	switch {
+	case v.Snapshot != "":
+		snap, err := d.loadSnapshot(ctx, v.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		return d.view(snapshotLookuper{entries: snap.Entries}), nil
+	case !v.Time.IsZero():
+		return d.At(v.Time), nil
+	}
+	return d, nil
+}
+
+// Diff compares the tree at from with the tree at to and calls fn with every
+// path that was added, removed or modified, parents before children, as the
+// walk finds them. An error returned by fn stops the diff. fn is called with
+// the driver's read lock held and must not use the driver.
+//
+// Files whose metadata and data references are identical in both versions
+// are skipped without reading anything, and metadata shared by both versions
+// is decoded once. A directory records the references of its children, so
+// directories whose references are identical are skipped with their whole
+// subtree; directories written before the references were recorded are
+// still descended.
+func (d *swarmDriver) Diff(ctx context.Context, from, to TreeVersion, fn func(Change) error) error {
+	if err := d.acquire(); err != nil {
+		return err
+	}
+	defer d.release()
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	logger.Debug("Diff Hit", slog.String("from", from.String()), slog.String("to", to.String()))
+	older, err := d.version(ctx, from)
+	if err != nil {
+		return fmt.Errorf("Diff: failed to open %s: %w", from, err)
+	}
+	newer, err := d.version(ctx, to)
+	if err != nil {
+		return fmt.Errorf("Diff: failed to open %s: %w", to, err)
+	}
+	td := &treeDiff{older: older, newer: newer, cache: make(map[string]metaData), fn: fn}
+	oldRoot, err := td.resolve(ctx, older, "/")
+	if err != nil {
+		return fmt.Errorf("Diff: %w", err)
+	}
+	newRoot, err := td.resolve(ctx, newer, "/")
+	if err != nil {
+		return fmt.Errorf("Diff: %w", err)
+	}
+	if err := td.diff(ctx, oldRoot, newRoot); err != nil {
+		return fmt.Errorf("Diff: %w", err)
+	}
+	return nil
+}
+
+// treeDiff holds the state of a single Diff.
+type treeDiff struct {
+	older, newer *swarmDriver
+	cache        map[string]metaData // Decoded metadata by reference.
+	fn           func(Change) error  // Called with every change found.
+}
+
+// resolve returns the node at path in d, or nil if the path does not exist.
+func (td *treeDiff) resolve(ctx context.Context, d *swarmDriver, path string) (*treeNode, error) {
+	node, err := d.resolveNodeCached(ctx, path, td.cache)
+	if errors.Is(err, lookuper.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func (td *treeDiff) diff(ctx context.Context, o, n *treeNode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch {
+	case o == nil && n == nil:
+		return nil
+	case o == nil:
+		return td.emitSubtree(ctx, td.newer, *n, ChangeAdded)
+	case n == nil:
+		return td.emitSubtree(ctx, td.older, *o, ChangeRemoved)
+	case o.Meta.IsDir != n.Meta.IsDir:
+		if err := td.emitSubtree(ctx, td.older, *o, ChangeRemoved); err != nil {
+			return err
+		}
+		return td.emitSubtree(ctx, td.newer, *n, ChangeAdded)
+	case !n.Meta.IsDir:
+		if o.MetaRef.Equal(n.MetaRef) && o.DataRef.Equal(n.DataRef) {
+			return nil
+		}
+		if o.Meta.Size == n.Meta.Size && o.Meta.ModTime == n.Meta.ModTime && o.DataRef.Equal(n.DataRef) {
+			return nil
+		}
+		return td.fn(change(ChangeModified, o, n))
+	case o.MetaRef.Equal(n.MetaRef) && n.Meta.Refs != nil:
+		// Both directories record the same subtree.
+		return nil
+	}
+	// Both are directories, descend into the union of their children.
+	children := append([]string{}, o.Meta.Children...)
+	if !o.MetaRef.Equal(n.MetaRef) {
+		seen := make(map[string]bool, len(children))
+		for _, child := range children {
+			seen[child] = true
+		}
+		for _, child := range n.Meta.Children {
+			if !seen[child] {
+				children = append(children, child)
+			}
+		}
+	}
+	for _, child := range children {
+		path := filepath.ToSlash(filepath.Join(n.Path, child))
+		oc, err := td.resolve(ctx, td.older, path)
+		if err != nil {
+			return err
+		}
+		nc, err := td.resolve(ctx, td.newer, path)
+		if err != nil {
+			return err
+		}
+		if err := td.diff(ctx, oc, nc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitSubtree reports node and all its descendants in d with kind.
+func (td *treeDiff) emitSubtree(ctx context.Context, d *swarmDriver, node treeNode, kind ChangeKind) error {
+	return d.walkNode(ctx, node, func(node treeNode) error {
+		if kind == ChangeAdded {
+			return td.fn(change(kind, nil, &node))
+		}
+		return td.fn(change(kind, &node, nil))
+	})
+}
+
+// change builds the Change record between the old and new node, either of
+// which may be nil.
+func change(kind ChangeKind, o, n *treeNode) Change {
+	c := Change{Kind: kind}
+	if o != nil {
+		c.Path = o.Path
+		c.IsDir = o.Meta.IsDir
+		c.OldSize = int64(o.Meta.Size)
+		c.OldModTime = time.Unix(o.Meta.ModTime, 0)
+		c.OldMeta = o.MetaRef
+		c.OldData = o.DataRef
+	}
+	if n != nil {
+		c.Path = n.Path
+		c.IsDir = n.Meta.IsDir
+		c.NewSize = int64(n.Meta.Size)
+		c.NewModTime = time.Unix(n.Meta.ModTime, 0)
+		c.NewMeta = n.MetaRef
+		c.NewData = n.DataRef
+	}
+	return c
+}
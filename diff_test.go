@@ -0,0 +1,174 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		"/repo/a":      "a1",
+		"/repo/b":      "b1",
+		"/repo/same/x": "x1",
+	} {
+		if err := d.PutContent(ctx, path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := time.Now()
+	time.Sleep(1100 * time.Millisecond)
+
+	if err := d.PutContent(ctx, "/repo/a", []byte("a22")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(ctx, "/repo/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/new/c", []byte("c1")); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := map[string]Change{}
+	err = d.Diff(ctx, TimeVersion(before), LiveVersion(), func(c Change) error {
+		changes[c.Path] = c
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, kind := range map[string]ChangeKind{
+		"/repo/a":     ChangeModified,
+		"/repo/b":     ChangeRemoved,
+		"/repo/new":   ChangeAdded,
+		"/repo/new/c": ChangeAdded,
+	} {
+		if changes[path].Kind != kind {
+			t.Fatalf("%s: want %s, got %q (changes %+v)", path, kind, changes[path].Kind, changes)
+		}
+	}
+	if len(changes) != 4 {
+		t.Fatalf("want 4 changes, got %+v", changes)
+	}
+	if c := changes["/repo/a"]; c.OldSize != 2 || c.NewSize != 3 || c.OldData.Equal(c.NewData) {
+		t.Fatalf("/repo/a: unexpected change %+v", c)
+	}
+	if !changes["/repo/new"].IsDir {
+		t.Fatal("/repo/new: want IsDir")
+	}
+
+	err = d.Diff(ctx, LiveVersion(), LiveVersion(), func(c Change) error {
+		t.Fatalf("identical trees: unexpected change %+v", c)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An error returned by fn stops the walk at the first change.
+	stop := errors.New("stop")
+	calls := 0
+	err = d.Diff(ctx, TimeVersion(before), LiveVersion(), func(c Change) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Fatalf("want the walk stopped after 1 change, got %d changes, %v", calls, err)
+	}
+}
+
+// recordingLookuper records the feeds looked up through it.
+type recordingLookuper struct {
+	Lookuper
+	mu  sync.Mutex
+	ids []string
+}
+
+func (l *recordingLookuper) Get(ctx context.Context, id string, version int64) (swarm.Address, error) {
+	l.mu.Lock()
+	l.ids = append(l.ids, id)
+	l.mu.Unlock()
+	return l.Lookuper.Get(ctx, id, version)
+}
+
+// lookedUp returns the feeds looked up below prefix since the last call.
+func (l *recordingLookuper) lookedUp(prefix string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var ids []string
+	for _, id := range l.ids {
+		if strings.HasPrefix(id, prefix) {
+			ids = append(ids, id)
+		}
+	}
+	l.ids = nil
+	return ids
+}
+
+func TestDiffSkipsUnchangedDirectories(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/same/a", "/same/deep/b", "/changed/c"} {
+		if err := d.PutContent(ctx, path, []byte(path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := time.Now()
+	time.Sleep(1100 * time.Millisecond)
+	if err := d.PutContent(ctx, "/changed/c", []byte("c2")); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recordingLookuper{Lookuper: d.lookuper}
+	d.lookuper = rec
+	var changes []Change
+	// fn may use the driver, the diff does not hold its lock.
+	err = d.Diff(ctx, TimeVersion(before), LiveVersion(), func(c Change) error {
+		changes = append(changes, c)
+		_, err := d.Stat(ctx, c.Path)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "/changed/c" || changes[0].Kind != ChangeModified {
+		t.Fatalf("want /changed/c modified, got %+v", changes)
+	}
+	for _, id := range rec.lookedUp("/same/") {
+		if id != "/same/mtdt" {
+			t.Fatalf("unchanged subtree was descended: looked up %s", id)
+		}
+	}
+
+	// Moving a directory records the references of the moved subtree.
+	if err := d.Move(ctx, "/same", "/moved/same"); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := d.getMetadata(ctx, "/moved/same")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deep, err := d.resolveNode(ctx, "/moved/same/deep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ref := moved.Refs["deep"]; !ref.Meta.Equal(deep.MetaRef) {
+		t.Fatalf("want ref of deep %s, got %s", deep.MetaRef, ref.Meta)
+	}
+}
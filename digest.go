@@ -0,0 +1,137 @@
+package swarmdriver
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+)
+
+// Digest algorithms the driver can record in file metadata. SHA256 is always
+// recorded, the others when enabled with WithDigests.
+const (
+	SHA256 = "sha256"
+	SHA384 = "sha384"
+	SHA512 = "sha512"
+)
+
+// digestHashes maps every supported digest algorithm to its hash.
+var digestHashes = map[string]func() hash.Hash{
+	SHA256: sha256.New,
+	SHA384: sha512.New384,
+	SHA512: sha512.New,
+}
+
+// ErrDigestMismatch is matched by DigestMismatchError with errors.Is.
+var ErrDigestMismatch = errors.New("swarmdriver: digest mismatch")
+
+// DigestMismatchError is returned by verified reads when the content of a
+// file does not hash to the digest recorded in its metadata, for example
+// because its data reference was swapped or a chunk was corrupted.
+type DigestMismatchError struct {
+	Path      string
+	Algorithm string
+	Want      string // Hex digest recorded in the metadata.
+	Got       string // Hex digest of the content read.
+}
+
+func (e DigestMismatchError) Error() string {
+	return fmt.Sprintf("swarmdriver: %s digest mismatch for %s: want %s, got %s", e.Algorithm, e.Path, e.Want, e.Got)
+}
+
+// Is reports DigestMismatchError as ErrDigestMismatch.
+func (e DigestMismatchError) Is(target error) bool {
+	return target == ErrDigestMismatch
+}
+
+// digester hashes content with several algorithms at once.
+type digester map[string]hash.Hash
+
+// newDigester returns a digester for algorithms, skipping unsupported ones.
+func newDigester(algorithms []string) digester {
+	dg := make(digester, len(algorithms))
+	for _, alg := range algorithms {
+		if newHash, ok := digestHashes[alg]; ok {
+			dg[alg] = newHash()
+		}
+	}
+	return dg
+}
+
+func (dg digester) Write(p []byte) (int, error) {
+	for _, h := range dg {
+		h.Write(p)
+	}
+	return len(p), nil
+}
+
+// sums returns the hex digest of everything written, by algorithm.
+func (dg digester) sums() map[string]string {
+	sums := make(map[string]string, len(dg))
+	for alg, h := range dg {
+		sums[alg] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums
+}
+
+// verify compares the digests of everything written with want, in the order
+// of the algorithms.
+func (dg digester) verify(path string, want map[string]string) error {
+	got := dg.sums()
+	algorithms := make([]string, 0, len(got))
+	for alg := range got {
+		algorithms = append(algorithms, alg)
+	}
+	sort.Strings(algorithms)
+	for _, alg := range algorithms {
+		if got[alg] != want[alg] {
+			return DigestMismatchError{Path: path, Algorithm: alg, Want: want[alg], Got: got[alg]}
+		}
+	}
+	return nil
+}
+
+// digests returns the digests of data for every algorithm the driver records.
+func (d *swarmDriver) digests(data []byte) map[string]string {
+	dg := newDigester(append([]string{SHA256}, d.digestAlgs...))
+	dg.Write(data)
+	return dg.sums()
+}
+
+// recordedDigester returns a digester for the algorithms recorded in want
+// that the driver supports, nil if there are none.
+func recordedDigester(want map[string]string) digester {
+	algorithms := make([]string, 0, len(want))
+	for alg := range want {
+		algorithms = append(algorithms, alg)
+	}
+	dg := newDigester(algorithms)
+	if len(dg) == 0 {
+		return nil
+	}
+	return dg
+}
+
+// verifyingReader hashes the content read through it and fails with
+// DigestMismatchError instead of io.EOF if it does not match.
+type verifyingReader struct {
+	r    io.Reader
+	path string
+	dg   digester
+	want map[string]string
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.dg.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if verr := v.dg.verify(v.path, v.want); verr != nil {
+			return n, verr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,112 @@
+package swarmdriver
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/ethereum/go-ethereum/common"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestDigests(t *testing.T) {
+	ctx := context.Background()
+	pk, err := beecrypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := teststore.NewSwarmInMemoryStore()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), s, false, WithSigner(beecrypto.NewDefaultSigner(pk)), WithDigests(SHA512), WithVerifiedReads())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	w, err := d.Writer(ctx, "/b", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("other content")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stat exposes the digests recorded on write.
+	sum256, sum512 := sha256.Sum256([]byte("hello")), sha512.Sum512([]byte("hello"))
+	fi, err := d.Stat(ctx, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, ok := fi.(FileInfo)
+	if !ok {
+		t.Fatalf("Stat returned %T, want FileInfo", fi)
+	}
+	if info.Digests[SHA256] != hex.EncodeToString(sum256[:]) || info.Digests[SHA512] != hex.EncodeToString(sum512[:]) {
+		t.Fatalf("unexpected digests %v", info.Digests)
+	}
+	fi, err = d.Stat(ctx, "/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum := sha256.Sum256([]byte("other content")); fi.(FileInfo).Digests[SHA256] != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected digests of committed file %v", fi.(FileInfo).Digests)
+	}
+	if got, err := d.GetContent(ctx, "/a"); err != nil || string(got) != "hello" {
+		t.Fatalf("GetContent: got %q, %v", got, err)
+	}
+
+	// Swap the data of /a for that of /b.
+	node, err := d.resolveNode(ctx, "/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.publisher.Put(ctx, "/a/data", time.Now().Unix(), node.DataRef); err != nil {
+		t.Fatal(err)
+	}
+	_, err = d.GetContent(ctx, "/a")
+	var derr storagedriver.Error
+	if !errors.As(err, &derr) || !errors.Is(derr.Detail, ErrDigestMismatch) {
+		t.Fatalf("GetContent: want digest mismatch, got %v", err)
+	}
+	for _, offset := range []int64{0, 3, 100} {
+		r, err := d.Reader(ctx, "/a", offset)
+		if err == nil {
+			_, err = io.ReadAll(r)
+			r.Close()
+		}
+		var mismatch DigestMismatchError
+		if !errors.As(err, &mismatch) && !(errors.As(err, &derr) && errors.As(derr.Detail, &mismatch)) {
+			t.Fatalf("Reader at %d: want digest mismatch, got %v", offset, err)
+		}
+		if mismatch.Algorithm != SHA256 || mismatch.Path != "/a" {
+			t.Fatalf("Reader at %d: unexpected mismatch %+v", offset, mismatch)
+		}
+	}
+
+	// Without verification the swapped content is returned.
+	unverified, err := New(ctx, common.HexToAddress("0xabcd"), s, false, WithSigner(beecrypto.NewDefaultSigner(pk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := unverified.GetContent(ctx, "/a"); err != nil || string(got) != "other content" {
+		t.Fatalf("GetContent unverified: got %q, %v", got, err)
+	}
+
+	if _, err := New(ctx, common.HexToAddress("0xabcd"), s, false, WithDigests("md5")); err == nil {
+		t.Fatal("New: want error for unsupported digest algorithm")
+	}
+}
@@ -0,0 +1,72 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+// ErrDriverClosed is returned by operations started after Close.
+var ErrDriverClosed = errors.New("swarmdriver: driver closed")
+
+// ErrReadOnly is returned by mutating operations on a read-only driver.
+var ErrReadOnly = errors.New("swarmdriver: read-only driver")
+
+// ErrUnsupportedStore is returned when an operation needs a store extension
+// interface, such as store.Iterator, that the store does not implement.
+var ErrUnsupportedStore = errors.New("swarmdriver: store does not support the operation")
+
+var (
+	// errZeroReference is returned when splitting yields no usable reference.
+	errZeroReference = errors.New("split returned zero reference")
+	// errChildNotFound is returned when a parent directory does not list a path.
+	errChildNotFound = errors.New("child not found")
+)
+
+// BackendUnavailableError is returned when the store refuses calls because
+// its circuit breaker is open. Unlike PathNotFoundError it signals a
+// temporary condition, so the request can be retried later.
+type BackendUnavailableError struct {
+	Path       string
+	DriverName string
+	Err        error
+}
+
+func (err BackendUnavailableError) Error() string {
+	return fmt.Sprintf("%s: backend unavailable for path %s: %v", err.DriverName, err.Path, err.Err)
+}
+
+func (err BackendUnavailableError) Unwrap() error {
+	return err.Err
+}
+
+// pathError maps a failure while operating on path to the error returned to
+// distribution:
+//   - PathNotFoundError only when the feed lookup found nothing,
+//   - context.Canceled and context.DeadlineExceeded as they are,
+//   - BackendUnavailableError while the store's circuit breaker is open,
+//   - QuotaExceededError as it is,
+//   - storagedriver.Error wrapping the cause for everything else.
+func (d *swarmDriver) pathError(path string, err error) error {
+	var quotaErr QuotaExceededError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled):
+		return context.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return context.DeadlineExceeded
+	case errors.Is(err, lookuper.ErrNotFound), errors.Is(err, errChildNotFound):
+		return storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+	case errors.Is(err, store.ErrCircuitOpen):
+		return BackendUnavailableError{Path: path, DriverName: d.Name(), Err: err}
+	case errors.As(err, &quotaErr):
+		return quotaErr
+	}
+	return storagedriver.Error{DriverName: d.Name(), Detail: err}
+}
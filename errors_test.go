@@ -0,0 +1,186 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+var errBoom = errors.New("boom")
+
+// faultyStore honours context cancellation and fails calls on demand.
+type faultyStore struct {
+	store.PutGetter
+	mu     sync.Mutex
+	getErr error
+	putErr error
+}
+
+func (s *faultyStore) fail(get, put error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getErr, s.putErr = get, put
+}
+
+func (s *faultyStore) Get(ctx context.Context, addr swarm.Address) (swarm.Chunk, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	err := s.getErr
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return s.PutGetter.Get(ctx, addr)
+}
+
+func (s *faultyStore) Put(ctx context.Context, ch swarm.Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	err := s.putErr
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.PutGetter.Put(ctx, ch)
+}
+
+func newFaultyDriver(t *testing.T) (*swarmDriver, *faultyStore) {
+	t.Helper()
+	fs := &faultyStore{PutGetter: teststore.NewSwarmInMemoryStore()}
+	d, err := New(context.Background(), common.HexToAddress("0xabcd"), fs, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, fs
+}
+
+func TestErrorNotFound(t *testing.T) {
+	ctx := context.Background()
+	d, _ := newFaultyDriver(t)
+
+	if _, err := d.GetContent(ctx, "/missing"); !errors.As(err, new(storagedriver.PathNotFoundError)) {
+		t.Fatalf("GetContent: want PathNotFoundError, got %#v", err)
+	}
+	if err := d.Delete(ctx, "/missing"); !errors.As(err, new(storagedriver.PathNotFoundError)) {
+		t.Fatalf("Delete: want PathNotFoundError, got %#v", err)
+	}
+	if err := d.Move(ctx, "/missing", "/elsewhere"); !errors.As(err, new(storagedriver.PathNotFoundError)) {
+		t.Fatalf("Move: want PathNotFoundError, got %#v", err)
+	}
+}
+
+func TestErrorContextPassedThrough(t *testing.T) {
+	d, _ := newFaultyDriver(t)
+	if err := d.PutContent(context.Background(), "/a/b", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := d.GetContent(ctx, "/a/b"); err != context.Canceled {
+		t.Fatalf("GetContent: want context.Canceled, got %#v", err)
+	}
+	if err := d.PutContent(ctx, "/a/c", []byte("content")); err != context.Canceled {
+		t.Fatalf("PutContent: want context.Canceled, got %#v", err)
+	}
+}
+
+func TestErrorStoreFailureWrapped(t *testing.T) {
+	ctx := context.Background()
+	d, fs := newFaultyDriver(t)
+	if err := d.PutContent(ctx, "/a/b", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	assertDriverError := func(op string, err error) {
+		t.Helper()
+		var derr storagedriver.Error
+		if !errors.As(err, &derr) {
+			t.Fatalf("%s: want storagedriver.Error, got %#v", op, err)
+		}
+		if !errors.Is(derr.Detail, errBoom) {
+			t.Fatalf("%s: want cause errBoom, got %v", op, derr.Detail)
+		}
+	}
+
+	fs.fail(nil, errBoom)
+	assertDriverError("PutContent", d.PutContent(ctx, "/a/c", []byte("content")))
+	assertDriverError("Delete", d.Delete(ctx, "/a/b"))
+	assertDriverError("Move", d.Move(ctx, "/a/b", "/a/d"))
+
+	fs.fail(errBoom, nil)
+	_, err := d.GetContent(ctx, "/a/b")
+	assertDriverError("GetContent", err)
+}
+
+func TestErrorBackendUnavailable(t *testing.T) {
+	ctx := context.Background()
+	d, fs := newFaultyDriver(t)
+	if err := d.PutContent(ctx, "/a/b", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	fs.fail(store.ErrCircuitOpen, store.ErrCircuitOpen)
+	if _, err := d.GetContent(ctx, "/a/b"); !errors.As(err, new(BackendUnavailableError)) {
+		t.Fatalf("GetContent: want BackendUnavailableError, got %#v", err)
+	}
+	if err := d.PutContent(ctx, "/a/c", []byte("content")); !errors.As(err, new(BackendUnavailableError)) {
+		t.Fatalf("PutContent: want BackendUnavailableError, got %#v", err)
+	}
+}
+
+func TestErrorCircuitBreakerOpens(t *testing.T) {
+	ctx := context.Background()
+	fs := &faultyStore{PutGetter: teststore.NewSwarmInMemoryStore()}
+	s := store.NewResilientStore(fs, store.ResilientOptions{
+		MaxAttempts:      2,
+		BaseDelay:        time.Millisecond,
+		FailureThreshold: 3,
+		OpenTimeout:      20 * time.Millisecond,
+	})
+	d, err := New(ctx, common.HexToAddress("0xabcd"), s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a/b", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Failing reads open the breaker, after which the driver reports the
+	// backend as unavailable instead of failing every read.
+	fs.fail(errBoom, nil)
+	opened := false
+	for i := 0; i < 5 && !opened; i++ {
+		_, err := d.GetContent(ctx, "/a/b")
+		if err == nil {
+			t.Fatal("GetContent: want error from failing store")
+		}
+		opened = errors.As(err, new(BackendUnavailableError))
+	}
+	if !opened {
+		t.Fatal("GetContent: want BackendUnavailableError once the breaker opens")
+	}
+	if err := d.PutContent(ctx, "/a/c", []byte("content")); !errors.As(err, new(BackendUnavailableError)) {
+		t.Fatalf("PutContent: want BackendUnavailableError, got %#v", err)
+	}
+
+	// Once the backend recovers a probe closes the breaker again.
+	fs.fail(nil, nil)
+	time.Sleep(30 * time.Millisecond)
+	if got, err := d.GetContent(ctx, "/a/b"); err != nil || string(got) != "content" {
+		t.Fatalf("GetContent after recovery: got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,115 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// FileInfo is the storagedriver.FileInfo returned by Stat and SwarmStat.
+// Callers that need more than the standard fields assert on it. Stat only
+// resolves the metadata feed and leaves DataRef and DataIndex zero, and
+// Updated and Encrypted reflect the metadata alone; SwarmStat fills them all.
+type FileInfo struct {
+	storagedriver.FileInfoInternal
+	// Digests maps each digest algorithm to the hex digest of the content
+	// recorded when the file was written. It is empty for directories and for
+	// files written before digests were recorded.
+	Digests   map[string]string
+	MetaRef   swarm.Address // Reference published on the metadata feed.
+	DataRef   swarm.Address // Reference published on the data feed, zero for directories and empty files.
+	MetaIndex uint64        // Index of the metadata feed update.
+	DataIndex uint64        // Index of the data feed update, zero for directories.
+	// Updated is the timestamp of the latest of the two feed updates. It is
+	// zero, as are the indexes, for views resolving feeds from a snapshot.
+	Updated time.Time
+	// Encrypted reports whether the references carry a decryption key, which
+	// makes them 64 bytes long instead of 32.
+	Encrypted bool
+}
+
+// lookupUpdate resolves the feed id through lk, with the index and timestamp
+// of the update if lk implements lookuper.UpdateLookuper.
+func lookupUpdate(ctx context.Context, lk Lookuper, id string, version int64) (lookuper.Update, error) {
+	if ul, ok := lk.(lookuper.UpdateLookuper); ok {
+		return ul.Lookup(ctx, id, version)
+	}
+	ref, err := lk.Get(ctx, id, version)
+	return lookuper.Update{Reference: ref}, err
+}
+
+// fileInfo resolves the metadata feed of path into a FileInfo, and the data
+// feed too if withData is set. It fails with lookuper.ErrNotFound if the path
+// has no metadata.
+func (d *swarmDriver) fileInfo(ctx context.Context, path string, withData bool) (FileInfo, error) {
+	var fi FileInfo
+	meta, err := lookupUpdate(ctx, d.lookuper, filepath.Join(path, "mtdt"), time.Now().Unix())
+	if err != nil {
+		return fi, fmt.Errorf("failed to lookup metadata for path %s: %w", path, err)
+	}
+	if isZeroAddress(meta.Reference) {
+		return fi, fmt.Errorf("metadata for path %s deleted: %w", path, lookuper.ErrNotFound)
+	}
+	var mtdt metaData
+	if err := d.getJSON(ctx, meta.Reference, &mtdt); err != nil {
+		return fi, fmt.Errorf("failed to read metadata for path %s: %w", path, err)
+	}
+	fi.FileInfoFields = storagedriver.FileInfoFields{
+		Path:    path,
+		IsDir:   mtdt.IsDir,
+		ModTime: time.Unix(mtdt.ModTime, 0),
+	}
+	fi.Digests = mtdt.Digests
+	fi.MetaRef = meta.Reference
+	fi.MetaIndex = meta.Index
+	updated := meta.Timestamp
+	ref := meta.Reference
+	if !mtdt.IsDir {
+		fi.FileInfoFields.Size = int64(mtdt.Size)
+	}
+	if !mtdt.IsDir && withData {
+		data, err := lookupUpdate(ctx, d.lookuper, filepath.Join(path, "data"), time.Now().Unix())
+		switch {
+		case err == nil:
+			if !isZeroAddress(data.Reference) {
+				fi.DataRef = data.Reference
+				ref = data.Reference
+			}
+			fi.DataIndex = data.Index
+			updated = max(updated, data.Timestamp)
+		case !errors.Is(err, lookuper.ErrNotFound):
+			return fi, fmt.Errorf("failed to lookup data for path %s: %w", path, err)
+		}
+	}
+	if updated > 0 {
+		fi.Updated = time.Unix(updated, 0)
+	}
+	fi.Encrypted = len(ref.Bytes()) == swarm.HashSize*2
+	return fi, nil
+}
+
+// SwarmStat is Stat returning the FileInfo with the swarm references behind
+// path, without a type assertion. Unlike Stat it also resolves the data feed
+// of files.
+func (d *swarmDriver) SwarmStat(ctx context.Context, path string) (FileInfo, error) {
+	if err := d.acquire(); err != nil {
+		return FileInfo{}, d.pathError(path, err)
+	}
+	defer d.release()
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	logger.Debug("SwarmStat Hit", slog.String("path", path))
+	fi, err := d.fileInfo(ctx, path, true)
+	if err != nil {
+		return FileInfo{}, d.pathError(path, err)
+	}
+	return fi, nil
+}
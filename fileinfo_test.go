@@ -0,0 +1,87 @@
+package swarmdriver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestSwarmStat(t *testing.T) {
+	ctx := context.Background()
+	for _, encrypt := range []bool{false, true} {
+		pk, err := beecrypto.GenerateSecp256k1Key()
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), encrypt, WithSigner(beecrypto.NewDefaultSigner(pk)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := d.PutContent(ctx, "/dir/a", []byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		first, err := d.SwarmStat(ctx, "/dir/a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		refSize := swarm.HashSize
+		if encrypt {
+			refSize *= 2
+		}
+		if first.Encrypted != encrypt || len(first.DataRef.Bytes()) != refSize || first.MetaRef.IsZero() {
+			t.Fatalf("encrypt %v: unexpected references %+v", encrypt, first)
+		}
+		if first.Size() != 5 || first.Digests[SHA256] == "" || first.Updated.IsZero() {
+			t.Fatalf("encrypt %v: unexpected file info %+v", encrypt, first)
+		}
+		node, err := d.resolveNode(ctx, "/dir/a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !first.DataRef.Equal(node.DataRef) {
+			t.Fatalf("want data reference %s, got %s", node.DataRef, first.DataRef)
+		}
+
+		// Overwriting advances both feeds.
+		if err := d.PutContent(ctx, "/dir/a", []byte("hello again")); err != nil {
+			t.Fatal(err)
+		}
+		second, err := d.SwarmStat(ctx, "/dir/a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if second.MetaIndex != first.MetaIndex+1 || second.DataIndex != first.DataIndex+1 || second.DataRef.Equal(first.DataRef) {
+			t.Fatalf("encrypt %v: want next updates after %+v, got %+v", encrypt, first, second)
+		}
+
+		// Stat only looks up the metadata feed.
+		rec := &recordingLookuper{Lookuper: d.lookuper}
+		d.lookuper = rec
+		fi, err := d.Stat(ctx, "/dir/a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := rec.lookedUp("/dir/a/"); len(ids) != 1 || ids[0] != "/dir/a/mtdt" {
+			t.Fatalf("encrypt %v: Stat looked up %v", encrypt, ids)
+		}
+		if info := fi.(FileInfo); !info.MetaRef.Equal(second.MetaRef) || !info.DataRef.IsZero() || info.Size() != 11 {
+			t.Fatalf("encrypt %v: unexpected Stat info %+v", encrypt, info)
+		}
+
+		dir, err := d.SwarmStat(ctx, "/dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !dir.IsDir() || !dir.DataRef.IsZero() || dir.Encrypted != encrypt {
+			t.Fatalf("encrypt %v: unexpected directory info %+v", encrypt, dir)
+		}
+		if _, err := d.SwarmStat(ctx, "/missing"); err == nil {
+			t.Fatal("SwarmStat: want error for missing path")
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestFollower(t *testing.T) {
+	ctx := context.Background()
+	chunkStore := teststore.NewSwarmInMemoryStore()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), chunkStore, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/a", []byte("a1")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFollower(ctx, common.HexToAddress("0x1234"), chunkStore); err == nil {
+		t.Fatal("want error following an owner without a tree")
+	}
+	f, err := NewFollower(ctx, d.Owner(), chunkStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := f.GetContent(ctx, "/repo/a"); err != nil || string(content) != "a1" {
+		t.Fatalf("want a1, got %q, %v", content, err)
+	}
+	if err := d.PutContent(ctx, "/repo/a", []byte("a2")); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := f.GetContent(ctx, "/repo/a"); err != nil || string(content) != "a2" {
+		t.Fatalf("want a2, got %q, %v", content, err)
+	}
+
+	_, writerErr := f.Writer(ctx, "/repo/b", false)
+	for op, err := range map[string]error{
+		"PutContent": f.PutContent(ctx, "/repo/b", []byte("b1")),
+		"Delete":     f.Delete(ctx, "/repo/a"),
+		"Move":       f.Move(ctx, "/repo/a", "/repo/b"),
+		"Writer":     writerErr,
+	} {
+		var derr storagedriver.Error
+		if !errors.As(err, &derr) || !errors.Is(derr.Detail, ErrReadOnly) {
+			t.Fatalf("%s: want ErrReadOnly, got %v", op, err)
+		}
+	}
+}
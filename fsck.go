@@ -0,0 +1,342 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/file/joiner"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+// FsckKind classifies an inconsistency found by Fsck.
+type FsckKind string
+
+const (
+	// FsckDanglingChild is a directory entry for a child without metadata or
+	// data. Repair removes the entry.
+	FsckDanglingChild FsckKind = "dangling-child"
+	// FsckOrphanedData is a data feed that no metadata accounts for: a
+	// directory entry whose child has data but no metadata, or a directory
+	// with a data reference. Repair clears the data of directories only;
+	// children with data are left for RecoverPaths.
+	FsckOrphanedData FsckKind = "orphaned-data"
+	// FsckDuplicateChild is a child listed more than once by its directory.
+	// Repair removes the duplicates.
+	FsckDuplicateChild FsckKind = "duplicate-child"
+	// FsckPathMismatch is metadata whose Path disagrees with its location.
+	// Repair rewrites Path.
+	FsckPathMismatch FsckKind = "path-mismatch"
+	// FsckSizeMismatch is file metadata whose Size differs from the length
+	// of its data. Repair records the length of the data.
+	FsckSizeMismatch FsckKind = "size-mismatch"
+	// FsckMissingData is a file without a data feed. It cannot be repaired.
+	FsckMissingData FsckKind = "missing-data"
+	// FsckUnreadableData is a data reference whose chunks cannot all be
+	// retrieved. It cannot be repaired.
+	FsckUnreadableData FsckKind = "unreadable-data"
+)
+
+// FsckIssue is a single inconsistency.
+type FsckIssue struct {
+	Kind     FsckKind `json:"kind"`
+	Path     string   `json:"path"`
+	Detail   string   `json:"detail,omitempty"`
+	Repaired bool     `json:"repaired"`
+}
+
+// FsckReport is the result of Fsck.
+type FsckReport struct {
+	Dirs   int         `json:"dirs"`   // Directories checked.
+	Files  int         `json:"files"`  // Files checked.
+	Bytes  int64       `json:"bytes"`  // Bytes of file data checked.
+	Issues []FsckIssue `json:"issues"` // Inconsistencies in the order they were found.
+}
+
+// FsckOptions configures Fsck.
+type FsckOptions struct {
+	// Repair fixes the inconsistencies that can be fixed without losing
+	// data. It is refused by read-only drivers.
+	Repair bool
+	// Fast only asks the store whether it holds the chunks of every file
+	// instead of reading them, if the store, or a store it wraps, implements
+	// store.Haser. It is cheaper, but leaves the content of the chunks
+	// unchecked, so corrupted or substituted chunks pass.
+	Fast bool
+}
+
+// Fsck walks the tree from "/" and reports every inconsistency between
+// directory entries, metadata and data. The data of every file is joined
+// through the driver's store and read in full, so every chunk is verified to
+// be retrievable and, with a validating store, to match its address.
+func (d *swarmDriver) Fsck(ctx context.Context, opts FsckOptions) (FsckReport, error) {
+	report := FsckReport{Issues: []FsckIssue{}}
+	if err := d.acquire(); err != nil {
+		return report, err
+	}
+	defer d.release()
+	if opts.Repair {
+		if d.readOnly {
+			return report, ErrReadOnly
+		}
+		d.mutex.Lock()
+		defer d.mutex.Unlock()
+	} else {
+		d.mutex.RLock()
+		defer d.mutex.RUnlock()
+	}
+	logger.Debug("Fsck Hit", slog.Bool("repair", opts.Repair))
+	f := &fsck{d: d, repair: opts.Repair, report: &report}
+	if opts.Fast {
+		f.haser, _ = store.AsHaser(d.store)
+	}
+	root, err := d.getMetadata(ctx, "/")
+	if err != nil {
+		return report, fmt.Errorf("Fsck: failed to get root metadata: %w", err)
+	}
+	if err := f.checkDir(ctx, "/", root); err != nil {
+		return report, fmt.Errorf("Fsck: %w", err)
+	}
+	if opts.Repair {
+		d.stats.invalidate()
+		// Repaired sizes and removed entries change what the quotas count.
+		if err := d.recomputeQuotas(ctx); err != nil {
+			return report, fmt.Errorf("Fsck: %w", err)
+		}
+	}
+	logger.Debug("Fsck: Done", slog.Int("issues", len(report.Issues)))
+	return report, nil
+}
+
+// fsck holds the state of a single Fsck.
+type fsck struct {
+	d      *swarmDriver
+	repair bool
+	report *FsckReport
+	haser  store.Haser // Set on a fast check if the store can tell whether it holds a chunk.
+}
+
+// add records an issue and, in repair mode, applies fix if there is one.
+func (f *fsck) add(kind FsckKind, path, detail string, fix func() error) error {
+	issue := FsckIssue{Kind: kind, Path: path, Detail: detail}
+	if f.repair && fix != nil {
+		if err := fix(); err != nil {
+			return fmt.Errorf("failed to repair %s at %s: %w", kind, path, err)
+		}
+		issue.Repaired = true
+	}
+	f.report.Issues = append(f.report.Issues, issue)
+	return nil
+}
+
+// checkDir checks the directory at path with metadata meta and everything
+// below it.
+func (f *fsck) checkDir(ctx context.Context, path string, meta metaData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.report.Dirs++
+	d := f.d
+	changed := false
+	if meta.Path != path {
+		if err := f.add(FsckPathMismatch, path, fmt.Sprintf("metadata path is %s", meta.Path), func() error {
+			meta.Path = path
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if ref, err := f.dataRef(ctx, path); err != nil {
+		return err
+	} else if !ref.IsZero() {
+		if err := f.add(FsckOrphanedData, path, "directory has a data reference", func() error {
+			return d.deleteData(ctx, path)
+		}); err != nil {
+			return err
+		}
+	}
+	// children collects the entries kept when the directory is repaired.
+	seen := make(map[string]bool, len(meta.Children))
+	children := make([]string, 0, len(meta.Children))
+	var files, dirs []string
+	childMeta := make(map[string]metaData, len(meta.Children))
+	for _, child := range meta.Children {
+		childPath := filepath.ToSlash(filepath.Join(path, child))
+		if seen[child] {
+			if err := f.add(FsckDuplicateChild, childPath, "", func() error {
+				changed = true
+				return nil
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		seen[child] = true
+		cm, err := d.getMetadata(ctx, childPath)
+		if errors.Is(err, lookuper.ErrNotFound) {
+			ref, err := f.dataRef(ctx, childPath)
+			if err != nil {
+				return err
+			}
+			if !ref.IsZero() {
+				// The data may still be recovered with RecoverPaths.
+				if err := f.add(FsckOrphanedData, childPath, "child has data but no metadata", nil); err != nil {
+					return err
+				}
+				children = append(children, child)
+				continue
+			}
+			if err := f.add(FsckDanglingChild, childPath, "", func() error {
+				changed = true
+				return nil
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get metadata of %s: %w", childPath, err)
+		}
+		children = append(children, child)
+		childMeta[childPath] = cm
+		if cm.IsDir {
+			dirs = append(dirs, childPath)
+		} else {
+			files = append(files, childPath)
+		}
+	}
+	if changed {
+		meta.Children = children
+		kept := make(map[string]childRef, len(children))
+		for _, child := range children {
+			if ref, ok := meta.Refs[child]; ok {
+				kept[child] = ref
+			}
+		}
+		meta.Refs = kept
+		if err := d.putMetadata(ctx, path, meta); err != nil {
+			return fmt.Errorf("failed to repair metadata of %s: %w", path, err)
+		}
+	}
+	for _, p := range files {
+		if err := f.checkFile(ctx, p, childMeta[p]); err != nil {
+			return err
+		}
+	}
+	for _, p := range dirs {
+		if err := f.checkDir(ctx, p, childMeta[p]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFile checks the file at path with metadata meta and reads its data.
+func (f *fsck) checkFile(ctx context.Context, path string, meta metaData) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.report.Files++
+	d := f.d
+	changed := false
+	if meta.Path != path {
+		if err := f.add(FsckPathMismatch, path, fmt.Sprintf("metadata path is %s", meta.Path), func() error {
+			meta.Path = path
+			changed = true
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	ref, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
+	switch {
+	case errors.Is(err, lookuper.ErrNotFound):
+		if err := f.add(FsckMissingData, path, "", nil); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("failed to lookup data of %s: %w", path, err)
+	default:
+		var size int64
+		if !isZeroAddress(ref) {
+			if size, err = f.checkData(ctx, ref); err != nil {
+				if err := f.add(FsckUnreadableData, path, err.Error(), nil); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		if size != int64(meta.Size) {
+			if err := f.add(FsckSizeMismatch, path, fmt.Sprintf("metadata size is %d, data is %d bytes", meta.Size, size), func() error {
+				meta.Size = int(size)
+				changed = true
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	if changed {
+		if err := d.putMetadata(ctx, path, meta); err != nil {
+			return fmt.Errorf("failed to repair metadata of %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// dataRef returns the data reference of path, zero if there is none.
+func (f *fsck) dataRef(ctx context.Context, path string) (swarm.Address, error) {
+	ref, err := f.d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
+	if errors.Is(err, lookuper.ErrNotFound) || (err == nil && isZeroAddress(ref)) {
+		return swarm.ZeroAddress, nil
+	}
+	if err != nil {
+		return swarm.ZeroAddress, fmt.Errorf("failed to lookup data of %s: %w", path, err)
+	}
+	return ref, nil
+}
+
+// checkData verifies that every chunk of the data at ref is retrievable and
+// returns the length of the data.
+func (f *fsck) checkData(ctx context.Context, ref swarm.Address) (int64, error) {
+	j, size, err := joiner.New(ctx, f.d.store, ref)
+	if err != nil {
+		return 0, err
+	}
+	if f.haser == nil {
+		n, err := io.Copy(io.Discard, j)
+		if err != nil {
+			return n, err
+		}
+		f.report.Bytes += n
+		return n, nil
+	}
+	err = j.IterateChunkAddresses(func(addr swarm.Address) error {
+		// Encrypted references carry the decryption key after the address.
+		if len(addr.Bytes()) > swarm.HashSize {
+			addr = swarm.NewAddress(addr.Bytes()[:swarm.HashSize])
+		}
+		has, err := f.haser.Has(ctx, addr)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return fmt.Errorf("chunk %s: %w", addr, storage.ErrNotFound)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	f.report.Bytes += size
+	return size, nil
+}
@@ -0,0 +1,147 @@
+package swarmdriver
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestFsck(t *testing.T) {
+	t.Run("read", func(t *testing.T) {
+		testFsck(t, teststore.NewSwarmInMemoryStore(), false)
+	})
+	t.Run("fast", func(t *testing.T) {
+		testFsck(t, teststore.NewSwarmInMemoryStore(), true)
+	})
+	t.Run("fast without haser", func(t *testing.T) {
+		// A store without extensions has the data read in full.
+		testFsck(t, &faultyStore{PutGetter: teststore.NewSwarmInMemoryStore()}, true)
+	})
+}
+
+func testFsck(t *testing.T, s store.PutGetter, fast bool) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/d/moved", "/d/sized", "/d/broken"} {
+		if err := d.PutContent(ctx, path, []byte("content")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now().Unix()
+	// Metadata that disagrees with its location and data.
+	if err := d.putMetadata(ctx, "/d/moved", metaData{Path: "/old", Size: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.putMetadata(ctx, "/d/sized", metaData{Path: "/d/sized", Size: 99}); err != nil {
+		t.Fatal(err)
+	}
+	// Data whose chunks are gone.
+	if err := d.publisher.Put(ctx, "/d/broken/data", now, swarm.RandAddress(t)); err != nil {
+		t.Fatal(err)
+	}
+	// A child with data but no metadata.
+	ref, err := d.split(ctx, []byte("orphan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.publisher.Put(ctx, filepath.Join("/d/orphan", "data"), now, ref); err != nil {
+		t.Fatal(err)
+	}
+	// A directory listing a duplicate, the orphan and a child that never existed.
+	dir, err := d.getMetadata(ctx, "/d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir.Children = append(dir.Children, "moved", "orphan", "ghost")
+	if err := d.putMetadata(ctx, "/d", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]FsckKind{
+		"/d/moved":  FsckPathMismatch,
+		"/d/sized":  FsckSizeMismatch,
+		"/d/broken": FsckUnreadableData,
+		"/d/orphan": FsckOrphanedData,
+		"/d/ghost":  FsckDanglingChild,
+	}
+	check := func(report FsckReport, want map[string]FsckKind, duplicates int) {
+		t.Helper()
+		got := map[string]FsckKind{}
+		var dups int
+		for _, issue := range report.Issues {
+			if issue.Kind == FsckDuplicateChild {
+				dups++
+				continue
+			}
+			got[issue.Path] = issue.Kind
+		}
+		if len(got) != len(want) || dups != duplicates {
+			t.Fatalf("want %v and %d duplicates, got %+v", want, duplicates, report.Issues)
+		}
+		for path, kind := range want {
+			if got[path] != kind {
+				t.Fatalf("%s: want %s, got %q (issues %+v)", path, kind, got[path], report.Issues)
+			}
+		}
+	}
+	report, err := d.Fsck(ctx, FsckOptions{Fast: fast})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(report, want, 1)
+	if report.Files != 3 {
+		t.Fatalf("want 3 files, got %d", report.Files)
+	}
+
+	if _, err := d.Fsck(ctx, FsckOptions{Repair: true, Fast: fast}); err != nil {
+		t.Fatal(err)
+	}
+	report, err = d.Fsck(ctx, FsckOptions{Fast: fast})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(report, map[string]FsckKind{
+		"/d/broken": FsckUnreadableData,
+		"/d/orphan": FsckOrphanedData,
+	}, 0)
+	if fi, err := d.Stat(ctx, "/d/sized"); err != nil || fi.Size() != 7 {
+		t.Fatalf("want repaired size 7, got %v, %v", fi, err)
+	}
+}
+
+func TestFsckValidatesChunks(t *testing.T) {
+	ctx := context.Background()
+	s := teststore.NewSwarmInMemoryStore()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), store.NewValidatingStore(s), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := d.lookuper.Get(ctx, "/a/data", time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Substitute the chunk with different content under the same address.
+	if err := s.Put(ctx, swarm.NewChunk(ref, []byte("forged content"))); err != nil {
+		t.Fatal(err)
+	}
+	report, err := d.Fsck(ctx, FsckOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Kind != FsckUnreadableData {
+		t.Fatalf("want the substituted chunk reported, got %+v", report.Issues)
+	}
+}
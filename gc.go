@@ -0,0 +1,251 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/file/joiner"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+// DefaultGCGrace is the grace period used when GCOptions.Grace is zero.
+const DefaultGCGrace = time.Hour
+
+// GCOptions configures GC.
+type GCOptions struct {
+	// DryRun reports what would be deleted without deleting anything.
+	DryRun bool
+	// Grace keeps unreachable chunks stored more recently than this, so the
+	// chunks of uploads whose feeds are not yet published survive. Zero
+	// means DefaultGCGrace.
+	Grace time.Duration
+}
+
+// GCReport is the result of GC.
+type GCReport struct {
+	Scanned    int   `json:"scanned"`    // Content-addressed chunks in the store.
+	Reachable  int   `json:"reachable"`  // Chunks reachable from the feeds.
+	Recent     int   `json:"recent"`     // Unreachable chunks kept for the grace period.
+	Swept      int   `json:"swept"`      // Unreachable chunks deleted, or that would be on a dry run.
+	SweptBytes int64 `json:"sweptBytes"` // Size of the swept chunks.
+}
+
+// GC deletes the chunks of the store that the tree no longer references.
+// Overwriting or deleting a path only publishes new feed updates, so without
+// it the data and metadata chunks of every version ever written stay in the
+// store.
+//
+// The mark phase joins every metadata and data reference reachable from "/",
+// every retained snapshot with the references it recorded, the quota table,
+// every entry of the audit log, and the data of the uploads a writer was
+// closed on without committing, which an appending writer may resume. The sweep phase iterates the store and
+// deletes the content-addressed chunks that were not marked and are older
+// than the grace period. Feed updates are single-owner chunks and are never
+// deleted, since sequence feed lookups walk every update of a feed. The
+// store, or a store it wraps, must implement store.Iterator and, unless on a
+// dry run, store.Deleter.
+//
+// Afterwards the past versions read through At or History may be gone, as
+// may the data of paths that only RecoverPaths could have brought back. The
+// store must hold the chunks of this driver only: chunks of other owners'
+// trees are unreachable from this one and would be deleted.
+func (d *swarmDriver) GC(ctx context.Context, opts GCOptions) (GCReport, error) {
+	var report GCReport
+	if err := d.acquire(); err != nil {
+		return report, err
+	}
+	defer d.release()
+	if !opts.DryRun && d.readOnly {
+		return report, ErrReadOnly
+	}
+	iterator, ok := store.AsIterator(d.store)
+	if !ok {
+		return report, fmt.Errorf("GC: store cannot iterate: %w", ErrUnsupportedStore)
+	}
+	deleter, ok := store.AsDeleter(d.store)
+	if !ok && !opts.DryRun {
+		return report, fmt.Errorf("GC: store cannot delete: %w", ErrUnsupportedStore)
+	}
+	if opts.Grace == 0 {
+		opts.Grace = DefaultGCGrace
+	}
+	// Hold the write lock so no reference is published between mark and sweep.
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	logger.Debug("GC Hit", slog.Bool("dryRun", opts.DryRun), slog.Duration("grace", opts.Grace))
+	start := time.Now()
+	marked, err := d.mark(ctx)
+	if err != nil {
+		return report, fmt.Errorf("GC: %w", err)
+	}
+	report.Reachable = len(marked)
+	err = iterator.Iterate(ctx, store.ContentChunk, func(addr swarm.Address, storedAt time.Time) error {
+		report.Scanned++
+		if _, ok := marked[addr.ByteString()]; ok {
+			return nil
+		}
+		if start.Sub(storedAt) < opts.Grace {
+			report.Recent++
+			return nil
+		}
+		ch, err := d.store.Get(ctx, addr)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read chunk %s: %w", addr, err)
+		}
+		report.Swept++
+		report.SweptBytes += int64(len(ch.Data()))
+		if opts.DryRun {
+			return nil
+		}
+		if err := deleter.Delete(ctx, addr); err != nil {
+			return fmt.Errorf("failed to delete chunk %s: %w", addr, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return report, fmt.Errorf("GC: %w", err)
+	}
+	logger.Debug("GC: Done", slog.Int("scanned", report.Scanned), slog.Int("swept", report.Swept))
+	return report, nil
+}
+
+// mark returns the addresses of every chunk reachable from the tree, the
+// snapshots, the quota table, the audit log and the pending uploads, keyed by
+// their byte string.
+func (d *swarmDriver) mark(ctx context.Context) (map[string]struct{}, error) {
+	marked := make(map[string]struct{})
+	markRef := func(ref swarm.Address) error {
+		if ref.IsZero() || isZeroAddress(ref) {
+			return nil
+		}
+		// A marked root implies its whole tree is marked, which saves joining
+		// the files snapshots share with the tree again.
+		if _, ok := marked[string(ref.Bytes()[:swarm.HashSize])]; ok {
+			return nil
+		}
+		j, _, err := joiner.New(ctx, d.store, ref)
+		if err != nil {
+			return fmt.Errorf("failed to create joiner for %s: %w", ref, err)
+		}
+		return j.IterateChunkAddresses(func(addr swarm.Address) error {
+			// Encrypted references carry the decryption key after the address.
+			if len(addr.Bytes()) > swarm.HashSize {
+				addr = swarm.NewAddress(addr.Bytes()[:swarm.HashSize])
+			}
+			marked[addr.ByteString()] = struct{}{}
+			return nil
+		})
+	}
+	err := d.walkTree(ctx, "/", func(node treeNode) error {
+		if err := markRef(node.MetaRef); err != nil {
+			return fmt.Errorf("failed to mark metadata of %s: %w", node.Path, err)
+		}
+		if err := markRef(node.DataRef); err != nil {
+			return fmt.Errorf("failed to mark data of %s: %w", node.Path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := d.markSnapshots(ctx, markRef); err != nil {
+		return nil, err
+	}
+	if ref, err := d.lookuper.Get(ctx, quotaFeed, time.Now().Unix()); err == nil {
+		if err := markRef(ref); err != nil {
+			return nil, fmt.Errorf("failed to mark quotas: %w", err)
+		}
+	}
+	if err := d.markUploads(ctx, markRef); err != nil {
+		return nil, err
+	}
+	ref, entry, err := d.auditHead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for !ref.IsZero() {
+		if err := markRef(ref); err != nil {
+			return nil, fmt.Errorf("failed to mark audit entry %d: %w", entry.Seq, err)
+		}
+		if entry.Prev.IsZero() {
+			break
+		}
+		ref = entry.Prev
+		entry = AuditEntry{}
+		if err := d.getJSON(ctx, ref, &entry); err != nil {
+			return nil, fmt.Errorf("failed to read audit entry: %w", err)
+		}
+	}
+	return marked, nil
+}
+
+// markSnapshots marks the snapshot index, every snapshot document and the
+// references recorded in them.
+func (d *swarmDriver) markSnapshots(ctx context.Context, markRef func(swarm.Address) error) error {
+	ref, err := d.lookuper.Get(ctx, snapshotIndexFeed, time.Now().Unix())
+	if err == nil {
+		if err := markRef(ref); err != nil {
+			return fmt.Errorf("failed to mark snapshot index: %w", err)
+		}
+	}
+	index, err := d.loadSnapshotIndex(ctx)
+	if err != nil {
+		return err
+	}
+	for _, info := range index {
+		if err := markRef(info.Reference); err != nil {
+			return fmt.Errorf("failed to mark snapshot %s: %w", info.Name, err)
+		}
+		var snap Snapshot
+		if err := d.getJSON(ctx, info.Reference, &snap); err != nil {
+			return fmt.Errorf("failed to read snapshot %s: %w", info.Name, err)
+		}
+		for path, entry := range snap.Entries {
+			if err := markRef(entry.Meta); err != nil {
+				return fmt.Errorf("failed to mark %s in snapshot %s: %w", path, info.Name, err)
+			}
+			if err := markRef(entry.Data); err != nil {
+				return fmt.Errorf("failed to mark %s in snapshot %s: %w", path, info.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// markUploads marks the list of pending uploads and the data their feeds
+// hold.
+func (d *swarmDriver) markUploads(ctx context.Context, markRef func(swarm.Address) error) error {
+	if ref, err := d.lookuper.Get(ctx, uploadsFeed, time.Now().Unix()); err == nil {
+		if err := markRef(ref); err != nil {
+			return fmt.Errorf("failed to mark uploads: %w", err)
+		}
+	}
+	uploads, err := d.loadUploads(ctx)
+	if err != nil {
+		return err
+	}
+	for path := range uploads {
+		ref, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
+		if errors.Is(err, lookuper.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lookup upload %s: %w", path, err)
+		}
+		if err := markRef(ref); err != nil {
+			return fmt.Errorf("failed to mark upload %s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestGC(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		ctx := context.Background()
+		s := teststore.NewSwarmInMemoryStore()
+		// The extensions are found below the validating store.
+		d, err := New(ctx, common.HexToAddress("0xabcd"), store.NewValidatingStore(s), encrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		big := make([]byte, 10000)
+		for i := range big {
+			big[i] = byte(i)
+		}
+		if err := d.PutContent(ctx, "/a/kept", big); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.PutContent(ctx, "/a/snapshotted", []byte("old")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := d.CreateSnapshot(ctx, "before"); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.PutContent(ctx, "/a/snapshotted", []byte("new")); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			big[0] = byte(i)
+			if err := d.PutContent(ctx, "/a/overwritten", big); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := d.Delete(ctx, "/a/kept"); err != nil {
+			t.Fatal(err)
+		}
+		if err := d.PutContent(ctx, "/a/kept", big); err != nil {
+			t.Fatal(err)
+		}
+
+		// Everything is within the default grace period, and feed updates are
+		// not even scanned.
+		report, err := d.GC(ctx, GCOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.Swept != 0 || report.Recent == 0 || report.Scanned == 0 || report.Scanned >= s.Len() {
+			t.Fatalf("encrypt=%v: grace period: got %+v", encrypt, report)
+		}
+		time.Sleep(time.Millisecond)
+		before := s.Len()
+		dry, err := d.GC(ctx, GCOptions{DryRun: true, Grace: time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dry.Swept == 0 || dry.SweptBytes == 0 || s.Len() != before {
+			t.Fatalf("encrypt=%v: dry run: got %+v with %d of %d chunks left", encrypt, dry, s.Len(), before)
+		}
+		report, err = d.GC(ctx, GCOptions{Grace: time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report != dry || s.Len() != before-report.Swept {
+			t.Fatalf("encrypt=%v: want %+v, got %+v with %d of %d chunks left", encrypt, dry, report, s.Len(), before)
+		}
+
+		// Everything reachable still reads.
+		for path, want := range map[string]string{"/a/kept": string(big), "/a/overwritten": string(big), "/a/snapshotted": "new"} {
+			got, err := d.GetContent(ctx, path)
+			if err != nil || string(got) != want {
+				t.Fatalf("encrypt=%v: %s: got %d bytes, %v", encrypt, path, len(got), err)
+			}
+		}
+		snap, err := d.MountSnapshot(ctx, "before")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := snap.GetContent(ctx, "/a/snapshotted"); err != nil || string(got) != "old" {
+			t.Fatalf("encrypt=%v: snapshot: got %q, %v", encrypt, got, err)
+		}
+		if err := d.VerifyAuditLog(ctx); err != nil {
+			t.Fatalf("encrypt=%v: %v", encrypt, err)
+		}
+		report, err = d.GC(ctx, GCOptions{Grace: time.Millisecond})
+		if err != nil || report.Swept != 0 {
+			t.Fatalf("encrypt=%v: second GC: got %+v, %v", encrypt, report, err)
+		}
+		// Writes keep working on the feeds GC kept.
+		if err := d.PutContent(ctx, "/a/overwritten", []byte("again")); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := d.GetContent(ctx, "/a/overwritten"); err != nil || string(got) != "again" {
+			t.Fatalf("encrypt=%v: after GC: got %q, %v", encrypt, got, err)
+		}
+	}
+}
+
+func TestGCUnsupportedStore(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), &faultyStore{PutGetter: teststore.NewSwarmInMemoryStore()}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.GC(ctx, GCOptions{DryRun: true}); !errors.Is(err, ErrUnsupportedStore) {
+		t.Fatalf("want ErrUnsupportedStore, got %v", err)
+	}
+}
+
+func TestGCFactoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := teststore.NewSwarmInMemoryStore()
+	// The factory wraps the store in a validating and a resilient store.
+	params := map[string]interface{}{"addr": common.HexToAddress("0xabcd"), "store": s, "encrypt": false, "validate": true}
+	sd, err := (&swarmDriverFactory{}).Create(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := sd.(*swarmDriver)
+	if err := d.PutContent(ctx, "/a", []byte("a1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("a2")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	report, err := d.GC(ctx, GCOptions{Grace: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Swept == 0 {
+		t.Fatalf("want the overwritten content swept, got %+v", report)
+	}
+	if got, err := d.GetContent(ctx, "/a"); err != nil || string(got) != "a2" {
+		t.Fatalf("want a2, got %q, %v", got, err)
+	}
+}
+
+func TestGCKeepsClosedUploads(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := d.Writer(ctx, "/uploads/a/data", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("first ")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := d.GC(ctx, GCOptions{Grace: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	w, err = d.Writer(ctx, "/uploads/a/data", true)
+	if err != nil {
+		t.Fatalf("resume after GC: %v", err)
+	}
+	if _, err := w.Write([]byte("second")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := d.GetContent(ctx, "/uploads/a/data"); err != nil || string(got) != "first second" {
+		t.Fatalf("want first second, got %q, %v", got, err)
+	}
+	if len(d.uploads) != 0 {
+		t.Fatalf("want no pending uploads after Commit, got %v", d.uploads)
+	}
+}
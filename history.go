@@ -0,0 +1,105 @@
+package swarmdriver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// Version is a single recorded update of a path's metadata or data feed.
+type Version struct {
+	Feed      string        // Feed the update belongs to, "mtdt" or "data".
+	Index     uint64        // Sequence index of the update within the feed.
+	Time      time.Time     // Timestamp the update was published with.
+	Reference swarm.Address // Published reference, zero for deletions and empty content.
+}
+
+// History lists every update of the metadata and data feeds of path, oldest
+// first. Updates are kept on Swarm, so the history survives overwrites and
+// deletes of the path.
+func (d *swarmDriver) History(ctx context.Context, path string) ([]Version, error) {
+	if err := d.acquire(); err != nil {
+		return nil, d.pathError(path, err)
+	}
+	defer d.release()
+	logger.Debug("History Hit", slog.String("path", path))
+	var versions []Version
+	for _, feed := range []string{"mtdt", "data"} {
+		updates, err := lookuper.History(ctx, d.store, d.owner, filepath.Join(path, feed))
+		if err != nil {
+			logger.Error("History: Failed to read feed", slog.String("path", path), slog.String("feed", feed), slog.String("error", err.Error()))
+			return nil, d.pathError(path, fmt.Errorf("History: %w", err))
+		}
+		for _, u := range updates {
+			versions = append(versions, Version{
+				Feed:      feed,
+				Index:     u.Index,
+				Time:      time.Unix(u.Timestamp, 0),
+				Reference: u.Reference,
+			})
+		}
+	}
+	if len(versions) == 0 {
+		return nil, d.pathError(path, lookuper.ErrNotFound)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Time.Before(versions[j].Time)
+	})
+	return versions, nil
+}
+
+// At returns a read-only view of the driver as it was at time t. GetContent,
+// Stat, List, Reader and Walk resolve every feed at t, mutating methods fail
+// with ErrReadOnly.
+func (d *swarmDriver) At(t time.Time) *swarmDriver {
+	return d.view(atLookuper{Lookuper: d.lookuper, at: t.Unix()})
+}
+
+// view derives a read-only driver sharing the store of d that resolves feeds
+// through lk.
+func (d *swarmDriver) view(lk Lookuper) *swarmDriver {
+	base := d
+	if d.base != nil {
+		base = d.base
+	}
+	return &swarmDriver{
+		store:        d.store,
+		owner:        d.owner,
+		encrypt:      d.encrypt,
+		lookuper:     lk,
+		splitter:     d.splitter,
+		closeTimeout: d.closeTimeout,
+		readOnly:     true,
+		base:         base,
+		watch:        d.watch,
+		digestAlgs:   d.digestAlgs,
+		verifyReads:  d.verifyReads,
+	}
+}
+
+// atLookuper resolves every lookup at a fixed point in time.
+type atLookuper struct {
+	Lookuper
+	at int64
+}
+
+func (l atLookuper) Get(ctx context.Context, id string, version int64) (swarm.Address, error) {
+	if version > l.at {
+		version = l.at
+	}
+	return l.Lookuper.Get(ctx, id, version)
+}
+
+func (l atLookuper) Lookup(ctx context.Context, id string, version int64) (lookuper.Update, error) {
+	if version > l.at {
+		version = l.at
+	}
+	return lookupUpdate(ctx, l.Lookuper, id, version)
+}
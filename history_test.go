@@ -0,0 +1,71 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestHistoryAndAt(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/manifest", []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	// Feed updates have second resolution.
+	time.Sleep(1100 * time.Millisecond)
+	if err := d.PutContent(ctx, "/repo/manifest", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/other", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := d.History(ctx, "/repo/manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data int
+	for _, v := range versions {
+		if v.Feed == "data" {
+			data++
+		}
+	}
+	if data != 2 {
+		t.Fatalf("want 2 data versions, got %d", data)
+	}
+
+	past := d.At(before)
+	content, err := past.GetContent(ctx, "/repo/manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "v1" {
+		t.Fatalf("want v1 in the past, got %q", content)
+	}
+	if _, err := past.GetContent(ctx, "/repo/other"); !errors.As(err, new(storagedriver.PathNotFoundError)) {
+		t.Fatalf("want PathNotFoundError for a later path, got %v", err)
+	}
+	var derr storagedriver.Error
+	if err := past.PutContent(ctx, "/repo/manifest", []byte("v3")); !errors.As(err, &derr) || !errors.Is(derr.Detail, ErrReadOnly) {
+		t.Fatalf("want ErrReadOnly, got %v", err)
+	}
+
+	content, err = d.GetContent(ctx, "/repo/manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "v2" {
+		t.Fatalf("want v2 now, got %q", content)
+	}
+}
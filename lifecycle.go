@@ -0,0 +1,145 @@
+package swarmdriver
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// acquire registers an operation with the driver. It fails once Close has
+// been called; otherwise release must be called when the operation is done.
+func (d *swarmDriver) acquire() error {
+	// Views share the lifecycle of the driver they were derived from.
+	if d.base != nil {
+		return d.base.acquire()
+	}
+	d.lifecycle.Lock()
+	defer d.lifecycle.Unlock()
+	if d.closed {
+		return ErrDriverClosed
+	}
+	d.inflight.Add(1)
+	return nil
+}
+
+// release marks an operation registered with acquire as finished.
+func (d *swarmDriver) release() {
+	if d.base != nil {
+		d.base.release()
+		return
+	}
+	d.inflight.Done()
+}
+
+// writerSet holds the writers of a driver that Close has to take care of.
+type writerSet map[*swarmFile]struct{}
+
+// trackWriter registers a new writer with the driver. It is called by Writer,
+// which holds an operation, so Close sees every writer it returned.
+func (d *swarmDriver) trackWriter(w *swarmFile) {
+	d.lifecycle.Lock()
+	defer d.lifecycle.Unlock()
+	if d.writers == nil {
+		d.writers = make(writerSet)
+	}
+	d.writers[w] = struct{}{}
+}
+
+// untrackWriter forgets a writer that was closed or cancelled.
+func (d *swarmDriver) untrackWriter(w *swarmFile) {
+	d.lifecycle.Lock()
+	defer d.lifecycle.Unlock()
+	delete(d.writers, w)
+}
+
+// driverReader is a reader returned by Reader. Every Read is an operation of
+// the driver, so Close waits for reads in progress and later reads fail with
+// ErrDriverClosed instead of reaching a closed store.
+type driverReader struct {
+	d      *swarmDriver
+	r      io.Reader
+	mu     sync.Mutex // Serializes reads and guards closed.
+	closed bool
+}
+
+// openReader wraps r for return by Reader.
+func (d *swarmDriver) openReader(r io.Reader) io.ReadCloser {
+	return &driverReader{d: d, r: r}
+}
+
+func (r *driverReader) Read(p []byte) (int, error) {
+	if err := r.d.acquire(); err != nil {
+		return 0, fmt.Errorf("Read: %w", err)
+	}
+	defer r.d.release()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return 0, fmt.Errorf("Read: already closed")
+	}
+	return r.r.Read(p)
+}
+
+func (r *driverReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+// Close shuts the driver down. New operations and writers are refused and in
+// flight operations such as commits and reads are waited for. Writers that
+// were neither closed nor cancelled are closed as by swarmFile.Close, which
+// publishes the data written to them without committing it; their methods
+// fail with ErrDriverClosed afterwards, as do reads from readers returned
+// before. Cached publisher and lookuper state is dropped and finally the
+// underlying store is closed. Closing a view is a no-op, the store belongs to
+// the driver it came from.
+func (d *swarmDriver) Close() error {
+	if d.base != nil {
+		return nil
+	}
+	d.lifecycle.Lock()
+	if d.closed {
+		d.lifecycle.Unlock()
+		return ErrDriverClosed
+	}
+	d.closed = true
+	d.lifecycle.Unlock()
+	logger.Debug("Close Hit")
+	// Wait for operations that were accepted before closing.
+	d.inflight.Wait()
+	d.closeWriters()
+	d.watch.closeAll()
+	// Flush publisher and lookuper state.
+	for _, c := range []interface{}{d.publisher, d.lookuper} {
+		if closer, ok := c.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				logger.Warn("Close: Failed to flush state", slog.String("error", err.Error()))
+			}
+		}
+	}
+	if err := d.store.Close(); err != nil {
+		return fmt.Errorf("Close: failed to close store: %w", err)
+	}
+	logger.Debug("Close: Success!")
+	return nil
+}
+
+// closeWriters closes the writers left open when the driver is closed. Their
+// errors are logged, as there is no caller left to report them to.
+func (d *swarmDriver) closeWriters() {
+	d.lifecycle.Lock()
+	writers := d.writers
+	d.writers = nil
+	d.lifecycle.Unlock()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for w := range writers {
+		if err := w.flush(); err != nil {
+			logger.Warn("Close: Failed to close writer", slog.String("path", w.path), slog.String("error", err.Error()))
+		}
+		w.closed = true
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -20,10 +21,20 @@ import (
 
 var log = logger.Logger("lookuper")
 
+// ErrNotFound is returned when a feed has no update at the requested version.
+var ErrNotFound = errors.New("lookuper: feed update not found")
+
 type Lookuper interface {
 	Get(ctx context.Context, id string, version int64) (swarm.Address, error)
 }
 
+// UpdateLookuper is implemented by lookupers that can return the whole
+// update, with its index and timestamp. The lookupers returned by New
+// implement it.
+type UpdateLookuper interface {
+	Lookup(ctx context.Context, id string, version int64) (Update, error)
+}
+
 type lookuperImpl struct {
 	store   store.PutGetter
 	owner   common.Address
@@ -35,45 +46,92 @@ func New(store store.PutGetter, owner common.Address) Lookuper {
 }
 
 func (l *lookuperImpl) Get(ctx context.Context, id string, version int64) (swarm.Address, error) {
+	update, err := l.Lookup(ctx, id, version)
+	if err != nil {
+		return swarm.ZeroAddress, err
+	}
+	return update.Reference, nil
+}
+
+func (l *lookuperImpl) Lookup(ctx context.Context, id string, version int64) (Update, error) {
 	lk, err := factory.New(l.store).NewLookup(feeds.Sequence, feeds.New([]byte(id), l.owner))
 	if err != nil {
-		return swarm.ZeroAddress, fmt.Errorf("failed creating lookuper %w", err)
+		return Update{}, fmt.Errorf("failed creating lookuper %w", err)
 	}
 
-	hint := uint64(l.hint(id))
-	ch, current, _, err := lk.At(ctx, version, hint)
+	if update, ok := l.hinted(ctx, id, version); ok {
+		return update, nil
+	}
+	ch, current, _, err := lk.At(ctx, version, 0)
 	if err != nil {
-		return swarm.ZeroAddress, fmt.Errorf("failed looking up key %w", err)
+		return Update{}, fmt.Errorf("failed looking up key %w", err)
 	}
 	if ch == nil {
-		return swarm.ZeroAddress, errors.New("invalid chunk lookup")
+		return Update{}, fmt.Errorf("lookup id %s: %w", id, ErrNotFound)
 	}
 
 	ref, ts, err := ParseFeedUpdate(ch)
 	if err != nil {
-		return swarm.ZeroAddress, fmt.Errorf("failed parsing feed update %w", err)
+		return Update{}, fmt.Errorf("failed parsing feed update %w", err)
 	}
 
-	l.setHint(id, current)
+	idx := l.setHint(id, current)
 	log.Debugf("lookup complete id %s version %d found %d ref %s", id, version, ts, ref.String())
 
-	return ref, nil
+	return Update{Index: uint64(idx), Timestamp: ts, Reference: ref}, nil
+}
+
+// Close drops the cached lookup hints.
+func (l *lookuperImpl) Close() error {
+	l.hintMap.Range(func(key, _ any) bool {
+		l.hintMap.Delete(key)
+		return true
+	})
+	return nil
 }
 
-func (l *lookuperImpl) hint(id string) int64 {
+// hinted resolves the lookup from the hinted index of id without searching
+// the feed from its first update. It succeeds if the hinted update is the
+// newest one at version: it is not newer than version and the update after
+// it is missing or newer than version.
+func (l *lookuperImpl) hinted(ctx context.Context, id string, version int64) (Update, bool) {
 	h, ok := l.hintMap.Load(id)
 	if !ok {
-		return 0
+		return Update{}, false
+	}
+	idx := uint64(h.(int64))
+	fg := feeds.NewGetter(l.store, feeds.New([]byte(id), l.owner))
+	ch, err := fg.Get(ctx, &index{idx})
+	if err != nil {
+		return Update{}, false
+	}
+	ref, ts, err := ParseFeedUpdate(ch)
+	if err != nil || ts > version {
+		return Update{}, false
 	}
-	return h.(int64)
+	next, err := fg.Get(ctx, &index{idx + 1})
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+	case err != nil:
+		return Update{}, false
+	default:
+		if _, nextTs, err := ParseFeedUpdate(next); err != nil || nextTs <= version {
+			return Update{}, false
+		}
+	}
+	log.Debugf("hinted lookup complete id %s version %d found %d ref %s", id, version, ts, ref.String())
+	return Update{Index: idx, Timestamp: ts, Reference: ref}, true
 }
 
-func (l *lookuperImpl) setHint(id string, index feeds.Index) {
+// setHint remembers index as the latest update of id and returns it.
+func (l *lookuperImpl) setHint(id string, index feeds.Index) int64 {
 	buf, err := index.MarshalBinary()
-	if err == nil {
-		hint := binary.BigEndian.Uint64(buf)
-		l.hintMap.Store(id, int64(hint))
+	if err != nil {
+		return 0
 	}
+	hint := int64(binary.BigEndian.Uint64(buf))
+	l.hintMap.Store(id, hint)
+	return hint
 }
 
 func ParseFeedUpdate(ch swarm.Chunk) (swarm.Address, int64, error) {
@@ -87,10 +145,14 @@ func ParseFeedUpdate(ch swarm.Chunk) (swarm.Address, int64, error) {
 	// possible values right now:
 	// unencrypted ref: span+timestamp+ref => 8+8+32=48
 	// encrypted ref: span+timestamp+ref+decryptKey => 8+8+64=80
-	if len(update) != 48 && len(update) != 80 {
+	// deleted or empty: span+timestamp => 8+8=16
+	if len(update) != 16 && len(update) != 48 && len(update) != 80 {
 		return swarm.ZeroAddress, 0, fmt.Errorf("invalid update")
 	}
 	ts := binary.BigEndian.Uint64(update[8:16])
+	if len(update) == 16 {
+		return swarm.ZeroAddress, int64(ts), nil
+	}
 	ref := swarm.NewAddress(update[16:])
 	return ref, int64(ts), nil
 }
@@ -111,7 +173,7 @@ func Latest(
 		}
 
 		if ch == nil {
-			return nil, 0, errors.New("invalid chunk")
+			return nil, 0, fmt.Errorf("latest id %s: %w", id, ErrNotFound)
 		}
 
 		_, ts, err := ParseFeedUpdate(ch)
@@ -122,3 +184,51 @@ func Latest(
 		return current, ts, nil
 	}
 }
+
+// Update is a single decoded update of a sequence feed.
+type Update struct {
+	Index     uint64
+	Timestamp int64
+	Reference swarm.Address
+}
+
+// History returns every update of the feed id owned by owner, oldest first.
+func History(ctx context.Context, getter storage.Getter, owner common.Address, id string) ([]Update, error) {
+	fg := feeds.NewGetter(getter, feeds.New([]byte(id), owner))
+	var updates []Update
+	for i := uint64(0); ; i++ {
+		ch, err := fg.Get(ctx, &index{i})
+		if errors.Is(err, storage.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed getting update %d of %s %w", i, id, err)
+		}
+		ref, ts, err := ParseFeedUpdate(ch)
+		if err != nil {
+			return nil, fmt.Errorf("failed parsing update %d of %s %w", i, id, err)
+		}
+		updates = append(updates, Update{Index: i, Timestamp: ts, Reference: ref})
+	}
+	return updates, nil
+}
+
+// index mirrors the unexported sequence feed index so updates can be
+// addressed directly.
+type index struct {
+	index uint64
+}
+
+func (i *index) String() string {
+	return strconv.FormatUint(i.index, 10)
+}
+
+func (i *index) MarshalBinary() ([]byte, error) {
+	indexBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(indexBytes, i.index)
+	return indexBytes, nil
+}
+
+func (i *index) Next(last int64, at uint64) feeds.Index {
+	return &index{i.index + 1}
+}
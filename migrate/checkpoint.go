@@ -0,0 +1,101 @@
+package migrate
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+)
+
+// Record describes a file that was copied and verified.
+type Record struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Digest  string    `json:"digest"`
+}
+
+// checkpoint is an append-only file of JSON records, one per line.
+type checkpoint struct {
+	mu   sync.Mutex // Guards f and done.
+	f    *os.File
+	done map[string]Record
+}
+
+// openCheckpoint loads the records of the checkpoint file at path and opens
+// it for appending. An empty path yields a checkpoint that records nothing.
+func openCheckpoint(path string) (*checkpoint, error) {
+	cp := &checkpoint{done: make(map[string]Record)}
+	if path == "" {
+		return cp, nil
+	}
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("migrate: failed to open checkpoint: %w", err)
+	default:
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec Record
+			if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+				// A line torn by a crash, the file is copied again.
+				continue
+			}
+			cp.done[rec.Path] = rec
+		}
+		err := scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("migrate: failed to read checkpoint: %w", err)
+		}
+	}
+	cp.f, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: failed to open checkpoint: %w", err)
+	}
+	return cp, nil
+}
+
+// Done reports whether fi was copied with its current size and modification
+// time.
+func (cp *checkpoint) Done(fi storagedriver.FileInfo) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	rec, ok := cp.done[fi.Path()]
+	return ok && rec.Size == fi.Size() && rec.ModTime.Equal(fi.ModTime())
+}
+
+// Add durably records rec.
+func (cp *checkpoint) Add(rec Record) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	cp.done[rec.Path] = rec
+	if cp.f == nil {
+		return nil
+	}
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("migrate: failed to encode checkpoint record: %w", err)
+	}
+	if _, err := cp.f.Write(append(buf, '\n')); err != nil {
+		return fmt.Errorf("migrate: failed to write checkpoint: %w", err)
+	}
+	if err := cp.f.Sync(); err != nil {
+		return fmt.Errorf("migrate: failed to sync checkpoint: %w", err)
+	}
+	return nil
+}
+
+func (cp *checkpoint) Close() error {
+	if cp.f == nil {
+		return nil
+	}
+	return cp.f.Close()
+}
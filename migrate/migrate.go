@@ -0,0 +1,200 @@
+// Package migrate copies the contents of any distribution storage driver into
+// a swarm driver.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+)
+
+// defaultWorkers is the number of files copied in parallel when no number is
+// configured.
+const defaultWorkers = 4
+
+// ErrDigestMismatch is returned when content read back from the target does
+// not match what was read from the source.
+var ErrDigestMismatch = errors.New("migrate: digest mismatch")
+
+// Target is the driver content is migrated into. The swarm driver implements
+// it.
+type Target interface {
+	storagedriver.StorageDriver
+	// SetModTime records t as the modification time of path.
+	SetModTime(ctx context.Context, path string, t time.Time) error
+}
+
+// Options configures Run.
+type Options struct {
+	// Workers bounds the number of files copied in parallel. Defaults to 4.
+	Workers int
+	// Checkpoint is the path of the checkpoint file. Files recorded in it with
+	// an unchanged size and modification time are not copied again. Empty
+	// disables checkpointing.
+	Checkpoint string
+	// Progress, if set, is called after every file, possibly concurrently.
+	Progress func(Record)
+}
+
+// Result summarizes a migration.
+type Result struct {
+	Copied  int   // Files copied.
+	Skipped int   // Files skipped because the checkpoint recorded them.
+	Bytes   int64 // Bytes copied.
+}
+
+// Run copies every file of src below "/" into dst. Content is streamed with
+// Reader and Writer, the modification time is preserved and the SHA-256
+// digest of the copy is verified by reading it back from dst. Every verified
+// file is appended to the checkpoint, so an interrupted migration resumes
+// where it stopped when run again with the same checkpoint.
+func Run(ctx context.Context, src storagedriver.StorageDriver, dst Target, opts Options) (Result, error) {
+	var result Result
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	cp, err := openCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return result, err
+	}
+	defer cp.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	files := make(chan storagedriver.FileInfo)
+	var (
+		mu       sync.Mutex // Guards result and firstErr.
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fi := range files {
+				rec, err := copyFile(ctx, src, dst, fi)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				if err := cp.Add(rec); err != nil {
+					fail(err)
+					continue
+				}
+				mu.Lock()
+				result.Copied++
+				result.Bytes += rec.Size
+				mu.Unlock()
+				if opts.Progress != nil {
+					opts.Progress(rec)
+				}
+			}
+		}()
+	}
+
+	walkErr := src.Walk(ctx, "/", func(fi storagedriver.FileInfo) error {
+		if fi.IsDir() {
+			return nil
+		}
+		if cp.Done(fi) {
+			mu.Lock()
+			result.Skipped++
+			mu.Unlock()
+			return nil
+		}
+		select {
+		case files <- fi:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(files)
+	wg.Wait()
+	if firstErr != nil {
+		return result, firstErr
+	}
+	var notFound storagedriver.PathNotFoundError
+	if errors.As(walkErr, &notFound) && notFound.Path == "/" {
+		// An empty source has no root to walk, there is nothing to copy.
+		return result, nil
+	}
+	if walkErr != nil {
+		return result, fmt.Errorf("migrate: failed to walk source: %w", walkErr)
+	}
+	return result, nil
+}
+
+// copyFile streams the file described by fi from src to dst and verifies the
+// copy.
+func copyFile(ctx context.Context, src storagedriver.StorageDriver, dst Target, fi storagedriver.FileInfo) (Record, error) {
+	path := fi.Path()
+	rec := Record{Path: path, Size: fi.Size(), ModTime: fi.ModTime()}
+	r, err := src.Reader(ctx, path, 0)
+	if err != nil {
+		return rec, fmt.Errorf("migrate: failed to read %s: %w", path, err)
+	}
+	defer r.Close()
+	w, err := dst.Writer(ctx, path, false)
+	if err != nil {
+		return rec, fmt.Errorf("migrate: failed to create writer for %s: %w", path, err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(w, io.TeeReader(r, h))
+	if err != nil {
+		w.Cancel(ctx)
+		return rec, fmt.Errorf("migrate: failed to copy %s: %w", path, err)
+	}
+	if err := w.Commit(ctx); err != nil {
+		return rec, fmt.Errorf("migrate: failed to commit %s: %w", path, err)
+	}
+	if err := w.Close(); err != nil {
+		return rec, fmt.Errorf("migrate: failed to close %s: %w", path, err)
+	}
+	rec.Size = n
+	rec.Digest = digest(h)
+	if err := dst.SetModTime(ctx, path, fi.ModTime()); err != nil {
+		return rec, fmt.Errorf("migrate: failed to set modification time of %s: %w", path, err)
+	}
+	if err := verify(ctx, dst, path, rec.Digest); err != nil {
+		return rec, err
+	}
+	return rec, nil
+}
+
+// verify reads path back from dst and compares its digest with want.
+func verify(ctx context.Context, dst Target, path, want string) error {
+	r, err := dst.Reader(ctx, path, 0)
+	if err != nil {
+		return fmt.Errorf("migrate: failed to read back %s: %w", path, err)
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("migrate: failed to read back %s: %w", path, err)
+	}
+	if got := digest(h); got != want {
+		return fmt.Errorf("%w: %s: want %s, got %s", ErrDigestMismatch, path, want, got)
+	}
+	return nil
+}
+
+func digest(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
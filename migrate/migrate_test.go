@@ -0,0 +1,97 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/distribution/distribution/v3/registry/storage/driver/inmemory"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	src := inmemory.New()
+	files := map[string][]byte{
+		"/docker/registry/v2/blobs/sha256/ab/abcd/data":                 bytes.Repeat([]byte("layer"), 2000),
+		"/docker/registry/v2/repositories/app/_layers/sha256/abcd/link": []byte("sha256:abcd"),
+		"/empty": {},
+	}
+	for path, content := range files {
+		if err := src.PutContent(ctx, path, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dst, err := swarmdriver.New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint")
+
+	result, err := Run(ctx, src, dst, Options{Workers: 2, Checkpoint: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != len(files) || result.Skipped != 0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	for path, content := range files {
+		got, err := dst.GetContent(ctx, path)
+		if err != nil || !bytes.Equal(got, content) {
+			t.Fatalf("%s: content differs, %v", path, err)
+		}
+		want, err := src.Stat(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fi, err := dst.Stat(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.ModTime().Unix() != want.ModTime().Unix() {
+			t.Fatalf("%s: want modification time %v, got %v", path, want.ModTime(), fi.ModTime())
+		}
+	}
+
+	// A second run resumes from the checkpoint.
+	result, err = Run(ctx, src, dst, Options{Checkpoint: checkpoint})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Copied != 0 || result.Skipped != len(files) {
+		t.Fatalf("unexpected result after restart %+v", result)
+	}
+}
+
+// vanishingDriver fails every walk as if path had been deleted meanwhile.
+type vanishingDriver struct {
+	storagedriver.StorageDriver
+	path string
+}
+
+func (d vanishingDriver) Walk(context.Context, string, storagedriver.WalkFn, ...func(*storagedriver.WalkOptions)) error {
+	return storagedriver.PathNotFoundError{Path: d.path, DriverName: "vanishing"}
+}
+
+func TestRunWalkNotFound(t *testing.T) {
+	ctx := context.Background()
+	dst, err := swarmdriver.New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A source without a root is empty.
+	if result, err := Run(ctx, vanishingDriver{StorageDriver: inmemory.New(), path: "/"}, dst, Options{}); err != nil || result.Copied != 0 {
+		t.Fatalf("empty source: want no error, got %+v, %v", result, err)
+	}
+	// A path vanishing during the walk fails the migration.
+	var notFound storagedriver.PathNotFoundError
+	if _, err := Run(ctx, vanishingDriver{StorageDriver: inmemory.New(), path: "/gone"}, dst, Options{}); !errors.As(err, &notFound) {
+		t.Fatalf("want PathNotFoundError, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package swarmdriver
+
+import (
+	"time"
+
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+// defaultCloseTimeout bounds swarmFile.Close when no timeout is configured.
+const defaultCloseTimeout = 30 * time.Second
+
+// Option configures optional behaviour of a swarmDriver.
+type Option func(*swarmDriver)
+
+// WithCloseTimeout bounds the time swarmFile.Close may spend storing data
+// that was written but not committed. A timeout that is not positive would
+// fail every such Close and is refused by New and NewFollower, like the
+// 'closetimeout' parameter of the factory.
+func WithCloseTimeout(timeout time.Duration) Option {
+	return func(d *swarmDriver) {
+		d.closeTimeout = timeout
+	}
+}
+
+// WithoutAuditLog stops recording PutContent, Commit, Move and Delete in the
+// driver's audit log, which is done by default. Mutations of a driver
+// created with it are missing from the log, which still verifies.
+func WithoutAuditLog() Option {
+	return func(d *swarmDriver) {
+		d.auditLog = false
+	}
+}
+
+// WithSigner makes signer the owner of the driver's feeds instead of a freshly
+// generated key. Reusing the signer of an existing tree reopens that tree.
+func WithSigner(signer beecrypto.Signer) Option {
+	return func(d *swarmDriver) {
+		d.signer = signer
+	}
+}
+
+// WithPinner pins the data of every file with pinner, counting the paths
+// that link each reference so it is unpinned when the last of them is
+// deleted or overwritten. Without it the store is used if it implements
+// store.Pinner, and nothing is pinned otherwise.
+func WithPinner(pinner store.Pinner) Option {
+	return func(d *swarmDriver) {
+		d.pinner = pinner
+	}
+}
+
+// WithDigests records the given algorithms, such as SHA512, in the metadata
+// of every file written besides SHA256, which is always recorded.
+func WithDigests(algorithms ...string) Option {
+	return func(d *swarmDriver) {
+		d.digestAlgs = append(d.digestAlgs, algorithms...)
+	}
+}
+
+// WithVerifiedReads checks the content returned by GetContent and Reader
+// against the digests recorded in the metadata of the file. GetContent fails
+// and the stream returned by Reader ends with DigestMismatchError instead of
+// io.EOF if the content does not match. Files without recorded digests are
+// read unchecked. Reader hashes the content before its offset too.
+func WithVerifiedReads() Option {
+	return func(d *swarmDriver) {
+		d.verifyReads = true
+	}
+}
@@ -0,0 +1,236 @@
+package swarmdriver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+// PinnedRef is a data reference kept pinned and the paths linking it.
+type PinnedRef struct {
+	Ref   swarm.Address `json:"ref"`
+	Paths []string      `json:"paths"`
+}
+
+// pinTracker counts the paths linking every data reference and pins a
+// reference while at least one path links it. The same layer is linked by
+// many repositories, so a reference is unpinned only when the last of them
+// is deleted or overwritten. It is guarded by the driver's mutex.
+type pinTracker struct {
+	pinner store.Pinner
+	paths  map[string]swarm.Address // Data reference of every file in the tree.
+	counts map[string]int           // Paths per data reference, keyed by its byte string.
+}
+
+// newPinTracker returns a tracker calling pinner, or the Pinner implemented
+// by s if pinner is nil. It returns nil, which tracks nothing, if there is
+// neither.
+func newPinTracker(s store.PutGetter, pinner store.Pinner) *pinTracker {
+	if pinner == nil {
+		var ok bool
+		if pinner, ok = store.AsPinner(s); !ok {
+			return nil
+		}
+	}
+	return &pinTracker{
+		pinner: pinner,
+		paths:  make(map[string]swarm.Address),
+		counts: make(map[string]int),
+	}
+}
+
+// link counts ref for a path and pins it if no path linked it before.
+func (p *pinTracker) link(ctx context.Context, ref swarm.Address) error {
+	key := ref.ByteString()
+	if p.counts[key] == 0 {
+		if err := p.pinner.Pin(ctx, ref); err != nil {
+			return fmt.Errorf("failed to pin %s: %w", ref, err)
+		}
+	}
+	p.counts[key]++
+	return nil
+}
+
+// unlink stops counting ref for a path and unpins it if no path links it
+// anymore. A failed unpin only leaves the content protected, so it is logged.
+func (p *pinTracker) unlink(ctx context.Context, ref swarm.Address) {
+	key := ref.ByteString()
+	if p.counts[key]--; p.counts[key] > 0 {
+		return
+	}
+	delete(p.counts, key)
+	if err := p.pinner.Unpin(ctx, ref); err != nil {
+		logger.Warn("pinTracker: Failed to unpin", slog.String("ref", ref.String()), slog.String("error", err.Error()))
+	}
+}
+
+// hold pins ref ahead of publishing it, so the content is protected before
+// any path links it. It is balanced by unhold, once the reference has been
+// set for its path or failed to publish.
+func (p *pinTracker) hold(ctx context.Context, ref swarm.Address) error {
+	if p == nil || isZeroAddress(ref) {
+		return nil
+	}
+	return p.link(ctx, ref)
+}
+
+// unhold releases a reference held by hold.
+func (p *pinTracker) unhold(ctx context.Context, ref swarm.Address) {
+	if p == nil || isZeroAddress(ref) {
+		return
+	}
+	p.unlink(ctx, ref)
+}
+
+// set records ref as the data of path, zero if it has none.
+func (p *pinTracker) set(ctx context.Context, path string, ref swarm.Address) error {
+	if p == nil {
+		return nil
+	}
+	old, had := p.paths[path]
+	if had && old.Equal(ref) {
+		return nil
+	}
+	// Pin the new reference before releasing the old one, they may share
+	// chunks.
+	if !ref.IsZero() && !isZeroAddress(ref) {
+		if err := p.link(ctx, ref); err != nil {
+			return err
+		}
+		p.paths[path] = ref
+	} else {
+		delete(p.paths, path)
+	}
+	if had {
+		p.unlink(ctx, old)
+	}
+	return nil
+}
+
+// drop forgets path and everything below it.
+func (p *pinTracker) drop(ctx context.Context, path string) {
+	if p == nil {
+		return
+	}
+	for _, q := range p.below(path) {
+		ref := p.paths[q]
+		delete(p.paths, q)
+		p.unlink(ctx, ref)
+	}
+}
+
+// move renames path and everything below it to dest, dropping what dest
+// held. The moved references stay pinned.
+func (p *pinTracker) move(ctx context.Context, path, dest string) {
+	if p == nil {
+		return
+	}
+	moved := make(map[string]swarm.Address)
+	for _, q := range p.below(path) {
+		moved[dest+strings.TrimPrefix(q, path)] = p.paths[q]
+		delete(p.paths, q)
+	}
+	var replaced []swarm.Address
+	for _, q := range p.below(dest) {
+		replaced = append(replaced, p.paths[q])
+		delete(p.paths, q)
+	}
+	for q, ref := range moved {
+		p.paths[q] = ref
+	}
+	for _, ref := range replaced {
+		p.unlink(ctx, ref)
+	}
+}
+
+// below returns path and the tracked paths below it.
+func (p *pinTracker) below(path string) []string {
+	var paths []string
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	for q := range p.paths {
+		if q == path || strings.HasPrefix(q, prefix) {
+			paths = append(paths, q)
+		}
+	}
+	return paths
+}
+
+// sync walks the tree and brings the tracker up to date with it, pinning
+// every reference it newly finds and unpinning those no longer linked. It
+// is used after operations that change many paths at once. The counts are
+// only kept in memory, so sync also rebuilds them when a driver is
+// constructed. It resolves every path of the tree, so its cost grows with
+// the tree rather than with the change; single writes use set instead.
+func (p *pinTracker) sync(ctx context.Context, d *swarmDriver) error {
+	if p == nil {
+		return nil
+	}
+	paths := make(map[string]swarm.Address)
+	counts := make(map[string]int)
+	err := d.walkTree(ctx, "/", func(node treeNode) error {
+		// Directories listing a child twice visit it twice.
+		if _, ok := paths[node.Path]; !ok && !node.DataRef.IsZero() {
+			paths[node.Path] = node.DataRef
+			counts[node.DataRef.ByteString()]++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk tree: %w", err)
+	}
+	for _, ref := range paths {
+		if key := ref.ByteString(); p.counts[key] == 0 {
+			if err := p.pinner.Pin(ctx, ref); err != nil {
+				return fmt.Errorf("failed to pin %s: %w", ref, err)
+			}
+			// Pin once for all the paths sharing ref.
+			p.counts[key] = counts[key]
+		}
+	}
+	for _, ref := range p.paths {
+		if key := ref.ByteString(); counts[key] == 0 {
+			if _, ok := p.counts[key]; !ok {
+				continue
+			}
+			delete(p.counts, key)
+			if err := p.pinner.Unpin(ctx, ref); err != nil {
+				logger.Warn("pinTracker: Failed to unpin", slog.String("ref", ref.String()), slog.String("error", err.Error()))
+			}
+		}
+	}
+	p.paths, p.counts = paths, counts
+	return nil
+}
+
+// PinnedRefs returns the data references the driver keeps pinned with the
+// paths linking each, sorted by reference. It is empty unless the driver has
+// a pinner, set with WithPinner or implemented by its store.
+func (d *swarmDriver) PinnedRefs() []PinnedRef {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	refs := []PinnedRef{}
+	if d.pins == nil {
+		return refs
+	}
+	byRef := make(map[string]*PinnedRef)
+	for path, ref := range d.pins.paths {
+		pin, ok := byRef[ref.ByteString()]
+		if !ok {
+			pin = &PinnedRef{Ref: ref}
+			byRef[ref.ByteString()] = pin
+		}
+		pin.Paths = append(pin.Paths, path)
+	}
+	for _, pin := range byRef {
+		sort.Strings(pin.Paths)
+		refs = append(refs, *pin)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Ref.String() < refs[j].Ref.String() })
+	return refs
+}
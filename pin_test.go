@@ -0,0 +1,164 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+// pinningStore is an in-memory store that pins, like a Swarm node.
+type pinningStore struct {
+	*teststore.SwarmInMemoryStore
+	*teststore.MemoryPinner
+}
+
+func TestPinning(t *testing.T) {
+	ctx := context.Background()
+	pk, err := beecrypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := teststore.NewSwarmInMemoryStore()
+	pinner := teststore.NewMemoryPinner()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), s, false, WithSigner(beecrypto.NewDefaultSigner(pk)), WithPinner(pinner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataRef := func(path string) swarm.Address {
+		t.Helper()
+		node, err := d.resolveNode(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return node.DataRef
+	}
+	checkPins := func(want ...PinnedRef) {
+		t.Helper()
+		if want == nil {
+			want = []PinnedRef{}
+		}
+		got := d.PinnedRefs()
+		// Order as PinnedRefs does.
+		if len(want) == 2 && want[0].Ref.String() > want[1].Ref.String() {
+			want[0], want[1] = want[1], want[0]
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("want pins %+v, got %+v", want, got)
+		}
+		var refs []swarm.Address
+		for _, pin := range want {
+			refs = append(refs, pin.Ref)
+		}
+		if pins := pinner.Pins(); len(pins) != len(refs) {
+			t.Fatalf("want %d references pinned, got %v", len(refs), pins)
+		}
+		for _, ref := range refs {
+			if !pinner.Pinned(ref) {
+				t.Fatalf("%s is not pinned", ref)
+			}
+		}
+	}
+
+	// The same layer linked from two repositories is pinned once.
+	for _, path := range []string{"/repo1/layer", "/repo2/layer"} {
+		if err := d.PutContent(ctx, path, []byte("layer")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	layer := dataRef("/repo1/layer")
+	checkPins(PinnedRef{Ref: layer, Paths: []string{"/repo1/layer", "/repo2/layer"}})
+	if calls := pinner.Calls(); calls != 1 {
+		t.Fatalf("want 1 pin call, got %d", calls)
+	}
+	// It stays pinned until the last path linking it goes.
+	if err := d.Delete(ctx, "/repo1"); err != nil {
+		t.Fatal(err)
+	}
+	checkPins(PinnedRef{Ref: layer, Paths: []string{"/repo2/layer"}})
+	w, err := d.Writer(ctx, "/repo2/layer", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("new layer")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	newLayer := dataRef("/repo2/layer")
+	checkPins(PinnedRef{Ref: newLayer, Paths: []string{"/repo2/layer"}})
+	// Moves keep the reference pinned under the new path.
+	if err := d.PutContent(ctx, "/repo3/layer", []byte("replaced")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Move(ctx, "/repo2", "/repo3"); err != nil {
+		t.Fatal(err)
+	}
+	checkPins(PinnedRef{Ref: newLayer, Paths: []string{"/repo3/layer"}})
+	// Empty files have no reference to pin.
+	if err := d.PutContent(ctx, "/repo3/layer", nil); err != nil {
+		t.Fatal(err)
+	}
+	checkPins()
+	if err := d.PutContent(ctx, "/repo3/layer", []byte("layer")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A reopened driver counts the existing tree and pins it with the
+	// store's pinner.
+	ps := pinningStore{SwarmInMemoryStore: s, MemoryPinner: teststore.NewMemoryPinner()}
+	d, err = New(ctx, common.HexToAddress("0xabcd"), store.NewValidatingStore(ps), false, WithSigner(beecrypto.NewDefaultSigner(pk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinner = ps.MemoryPinner
+	checkPins(PinnedRef{Ref: layer, Paths: []string{"/repo3/layer"}})
+	if err := d.Delete(ctx, "/"); err != nil {
+		t.Fatal(err)
+	}
+	checkPins()
+}
+
+// feedFailingStore fails to store feed updates once armed.
+type feedFailingStore struct {
+	store.PutGetter
+	armed atomic.Bool
+}
+
+func (s *feedFailingStore) Put(ctx context.Context, ch swarm.Chunk) error {
+	if s.armed.Load() && store.TypeOf(ch) == store.SingleOwnerChunk {
+		return errors.New("feed update lost")
+	}
+	return s.PutGetter.Put(ctx, ch)
+}
+
+func TestPinningFailedPublish(t *testing.T) {
+	ctx := context.Background()
+	s := &feedFailingStore{PutGetter: teststore.NewSwarmInMemoryStore()}
+	pinner := teststore.NewMemoryPinner()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), s, false, WithPinner(pinner))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	pins, calls := pinner.Pins(), pinner.Calls()
+	s.armed.Store(true)
+	if err := d.PutContent(ctx, "/b", []byte("b")); err == nil {
+		t.Fatal("want error publishing /b")
+	}
+	// The data was pinned before publishing and released when it failed.
+	if pinner.Calls() != calls+2 || !reflect.DeepEqual(pinner.Pins(), pins) {
+		t.Fatalf("want pin and unpin leaving %v, got %v after %d calls", pins, pinner.Pins(), pinner.Calls()-calls)
+	}
+}
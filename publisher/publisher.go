@@ -3,6 +3,7 @@ package publisher
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"strconv"
 	"sync"
@@ -12,6 +13,8 @@ import (
 	"github.com/ethersphere/bee/pkg/storage"
 	"github.com/ethersphere/bee/pkg/swarm"
 	logger "github.com/ipfs/go-log/v2"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
 )
 
 var log = logger.Logger("publisher")
@@ -44,11 +47,15 @@ func (p *pubImpl) Put(ctx context.Context, id string, version int64, ref swarm.A
 	state, found := p.updaterMap.Load(id)
 	if !found {
 		currIndex, at, err := p.loader(ctx, id)
-		if err == nil {
+		switch {
+		case err == nil:
 			log.Infof("publisher: loaded initial version %s timestamp %d", currIndex, at)
 			nxtIndex = currIndex.Next(at, uint64(version))
-		} else {
+		case errors.Is(err, lookuper.ErrNotFound):
 			nxtIndex = new(index)
+		default:
+			// Starting over at index 0 would shadow the existing updates.
+			return fmt.Errorf("publisher: failed to load latest index: %w", err)
 		}
 	} else {
 		fstate := state.(feedState)
@@ -67,6 +74,16 @@ func (p *pubImpl) Put(ctx context.Context, id string, version int64, ref swarm.A
 	return nil
 }
 
+// Close drops the cached feed indexes. Every Put has already been written to
+// the store, so there is nothing left to flush.
+func (p *pubImpl) Close() error {
+	p.updaterMap.Range(func(key, _ any) bool {
+		p.updaterMap.Delete(key)
+		return true
+	})
+	return nil
+}
+
 func (p *pubImpl) update(
 	ctx context.Context,
 	id string,
@@ -0,0 +1,322 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// quotaFeed is the feed holding the reference of the quota table. Like the
+// other driver feeds it cannot collide with a path.
+const quotaFeed = "swarmdriver/quota"
+
+// Quota limits the committed bytes of the files below a path prefix.
+type Quota struct {
+	Prefix string `json:"prefix"` // Path the quota applies to, with everything below it.
+	Limit  int64  `json:"limit"`  // Bytes the files below Prefix may take.
+	Usage  int64  `json:"usage"`  // Bytes the files below Prefix take.
+}
+
+// QuotaExceededError is returned by PutContent, Commit and the Close of a
+// writer when the write would take the usage of a quota above its limit.
+type QuotaExceededError struct {
+	Path       string
+	DriverName string
+	Prefix     string // Prefix of the exceeded quota.
+	Limit      int64  // Limit of the exceeded quota.
+	Usage      int64  // Usage of the quota before the write.
+	Size       int64  // Bytes the write would add.
+}
+
+func (err QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s: quota of %s exceeded by write to %s: %d of %d bytes used, %d more requested",
+		err.DriverName, err.Prefix, err.Path, err.Usage, err.Limit, err.Size)
+}
+
+// underPrefix reports whether path is prefix or below it.
+func underPrefix(path, prefix string) bool {
+	return path == prefix || prefix == "/" || strings.HasPrefix(path, prefix+"/")
+}
+
+// loadQuotas reads the quota table, which is empty until the first quota is
+// set.
+func (d *swarmDriver) loadQuotas(ctx context.Context) (map[string]Quota, error) {
+	quotas := make(map[string]Quota)
+	ref, err := d.lookuper.Get(ctx, quotaFeed, time.Now().Unix())
+	if errors.Is(err, lookuper.ErrNotFound) || (err == nil && isZeroAddress(ref)) {
+		return quotas, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup quotas: %w", err)
+	}
+	var list []Quota
+	if err := d.getJSON(ctx, ref, &list); err != nil {
+		return nil, fmt.Errorf("failed to read quotas: %w", err)
+	}
+	for _, q := range list {
+		quotas[q.Prefix] = q
+	}
+	return quotas, nil
+}
+
+// storeQuotas writes the quota table and publishes it.
+func (d *swarmDriver) storeQuotas(ctx context.Context) error {
+	ref, err := d.putJSON(ctx, sortedQuotas(d.quotas))
+	if err != nil {
+		return fmt.Errorf("failed to store quotas: %w", err)
+	}
+	if err := d.publisher.Put(ctx, quotaFeed, time.Now().Unix(), ref); err != nil {
+		return fmt.Errorf("failed to publish quotas: %w", err)
+	}
+	return nil
+}
+
+func sortedQuotas(quotas map[string]Quota) []Quota {
+	list := make([]Quota, 0, len(quotas))
+	for _, q := range quotas {
+		list = append(list, q)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Prefix < list[j].Prefix })
+	return list
+}
+
+// quotaOverlaps reports whether a quota covers path or a path below it.
+func (d *swarmDriver) quotaOverlaps(path string) bool {
+	for prefix := range d.quotas {
+		if underPrefix(path, prefix) || underPrefix(prefix, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQuota fails with QuotaExceededError if replacing the file at path with
+// size bytes exceeds a quota covering path. It returns the size of the file
+// being replaced, zero if there is none.
+func (d *swarmDriver) checkQuota(ctx context.Context, path string, size int64) (int64, error) {
+	path = filepath.ToSlash(filepath.Clean(path))
+	var old int64
+	looked := false
+	for _, prefix := range sortedQuotaPrefixes(d.quotas) {
+		if !underPrefix(path, prefix) {
+			continue
+		}
+		if !looked {
+			meta, err := d.getMetadata(ctx, path)
+			if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+				return 0, fmt.Errorf("failed to get metadata of %s: %w", path, err)
+			}
+			if err == nil && !meta.IsDir {
+				old = int64(meta.Size)
+			}
+			looked = true
+		}
+		q := d.quotas[prefix]
+		if size > old && q.Usage-old+size > q.Limit {
+			return 0, QuotaExceededError{
+				Path:       path,
+				DriverName: d.Name(),
+				Prefix:     prefix,
+				Limit:      q.Limit,
+				Usage:      q.Usage,
+				Size:       size - old,
+			}
+		}
+	}
+	return old, nil
+}
+
+func sortedQuotaPrefixes(quotas map[string]Quota) []string {
+	prefixes := make([]string, 0, len(quotas))
+	for prefix := range quotas {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes
+}
+
+// quotaFiles returns the size of every file at or below path, keyed by path.
+// It is empty if no quota overlaps path.
+func (d *swarmDriver) quotaFiles(ctx context.Context, path string) (map[string]int64, error) {
+	files := make(map[string]int64)
+	if !d.quotaOverlaps(path) {
+		return files, nil
+	}
+	err := d.walkTree(ctx, path, func(node treeNode) error {
+		if !node.Meta.IsDir {
+			files[node.Path] = int64(node.Meta.Size)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+		return nil, fmt.Errorf("failed to size %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// chargeQuota moves the usage of the quotas from the removed files to the
+// added ones and publishes the quota table if it changed.
+func (d *swarmDriver) chargeQuota(ctx context.Context, removed, added map[string]int64) error {
+	changed := false
+	for prefix, q := range d.quotas {
+		usage := q.Usage
+		for path, size := range removed {
+			if underPrefix(path, prefix) {
+				usage -= size
+			}
+		}
+		for path, size := range added {
+			if underPrefix(path, prefix) {
+				usage += size
+			}
+		}
+		if usage != q.Usage {
+			q.Usage = usage
+			d.quotas[prefix] = q
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return d.storeQuotas(ctx)
+}
+
+// recomputeQuotas sets the usage of every quota from the metadata of the
+// tree and publishes the quota table if it changed. It is used after
+// operations that change many paths at once.
+func (d *swarmDriver) recomputeQuotas(ctx context.Context) error {
+	if len(d.quotas) == 0 {
+		return nil
+	}
+	files, err := d.quotaFiles(ctx, "/")
+	if err != nil {
+		return err
+	}
+	changed := false
+	for prefix, q := range d.quotas {
+		var usage int64
+		for path, size := range files {
+			if underPrefix(path, prefix) {
+				usage += size
+			}
+		}
+		if usage != q.Usage {
+			q.Usage = usage
+			d.quotas[prefix] = q
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return d.storeQuotas(ctx)
+}
+
+// SetQuota limits the committed bytes of the files at or below prefix to
+// limit, replacing the quota of prefix if there is one. A limit of zero
+// removes the quota. The usage is computed from the metadata of the tree, so
+// a quota may be set below the current usage, in which case only writes that
+// shrink files succeed below prefix.
+//
+// Usage is the sum of the sizes recorded in file metadata. Files sharing data
+// are counted once per path, and Move and Delete keep the usage up to date
+// but are never refused. Every write, move or delete that changes the usage
+// of a quota republishes the quota table, one more feed update per mutation
+// below a prefix with a quota.
+func (d *swarmDriver) SetQuota(ctx context.Context, prefix string, limit int64) (Quota, error) {
+	if err := d.acquire(); err != nil {
+		return Quota{}, err
+	}
+	defer d.release()
+	if d.readOnly {
+		return Quota{}, ErrReadOnly
+	}
+	if err := isValidPath(prefix); err != nil {
+		return Quota{}, fmt.Errorf("SetQuota: %w", err)
+	}
+	if limit < 0 {
+		return Quota{}, fmt.Errorf("SetQuota: negative limit %d", limit)
+	}
+	prefix = filepath.ToSlash(filepath.Clean(prefix))
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	logger.Debug("SetQuota Hit", slog.String("prefix", prefix), slog.Int64("limit", limit))
+	if limit == 0 {
+		if _, ok := d.quotas[prefix]; !ok {
+			return Quota{Prefix: prefix}, nil
+		}
+		delete(d.quotas, prefix)
+		if err := d.storeQuotas(ctx); err != nil {
+			return Quota{}, fmt.Errorf("SetQuota: %w", err)
+		}
+		return Quota{Prefix: prefix}, nil
+	}
+	prev, had := d.quotas[prefix]
+	q := Quota{Prefix: prefix, Limit: limit}
+	// Register the quota first, quotaFiles only sizes paths a quota covers.
+	d.quotas[prefix] = q
+	files, err := d.quotaFiles(ctx, prefix)
+	if err != nil {
+		if had {
+			d.quotas[prefix] = prev
+		} else {
+			delete(d.quotas, prefix)
+		}
+		return Quota{}, fmt.Errorf("SetQuota: %w", err)
+	}
+	for _, size := range files {
+		q.Usage += size
+	}
+	d.quotas[prefix] = q
+	if err := d.storeQuotas(ctx); err != nil {
+		return Quota{}, fmt.Errorf("SetQuota: %w", err)
+	}
+	return q, nil
+}
+
+// Quotas returns the quotas sorted by prefix. Read-only drivers read the
+// quotas last published by the owner of the tree.
+func (d *swarmDriver) Quotas(ctx context.Context) ([]Quota, error) {
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	if d.quotas != nil {
+		return sortedQuotas(d.quotas), nil
+	}
+	quotas, err := d.loadQuotas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Quotas: %w", err)
+	}
+	return sortedQuotas(quotas), nil
+}
+
+// RecomputeQuotas recomputes the usage of every quota from the tree, for
+// example after a crash between a write and the publication of the quota
+// table, and returns the quotas sorted by prefix.
+func (d *swarmDriver) RecomputeQuotas(ctx context.Context) ([]Quota, error) {
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	if d.readOnly {
+		return nil, ErrReadOnly
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	logger.Debug("RecomputeQuotas Hit")
+	if err := d.recomputeQuotas(ctx); err != nil {
+		return nil, fmt.Errorf("RecomputeQuotas: %w", err)
+	}
+	return sortedQuotas(d.quotas), nil
+}
@@ -0,0 +1,134 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestQuotas(t *testing.T) {
+	ctx := context.Background()
+	pk, err := beecrypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := teststore.NewSwarmInMemoryStore()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), s, false, WithSigner(beecrypto.NewDefaultSigner(pk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas := func(want ...Quota) {
+		t.Helper()
+		got, err := d.Quotas(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = []Quota{}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("want quotas %+v, got %+v", want, got)
+		}
+	}
+	if err := d.PutContent(ctx, "/teams/a/old", []byte("1234")); err != nil {
+		t.Fatal(err)
+	}
+	// The usage of a new quota is computed from the tree.
+	if _, err := d.SetQuota(ctx, "/teams/a", 10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.SetQuota(ctx, "/teams", 20); err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 4}, Quota{Prefix: "/teams/a", Limit: 10, Usage: 4})
+
+	// Writes are charged to every quota covering them and refused above the
+	// tightest one.
+	if err := d.PutContent(ctx, "/teams/a/new", []byte("123456")); err != nil {
+		t.Fatal(err)
+	}
+	err = d.PutContent(ctx, "/teams/a/more", []byte("1"))
+	var quotaErr QuotaExceededError
+	if !errors.As(err, &quotaErr) || quotaErr.Prefix != "/teams/a" || quotaErr.Usage != 10 || quotaErr.Size != 1 {
+		t.Fatalf("want QuotaExceededError of /teams/a, got %v", err)
+	}
+	w, err := d.Writer(ctx, "/teams/a/more", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Commit(ctx); !errors.As(err, &quotaErr) {
+		t.Fatalf("want QuotaExceededError from Commit, got %v", err)
+	}
+	// Closing a writer publishes its data and is refused too.
+	w, err = d.Writer(ctx, "/teams/a/more", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); !errors.As(err, &quotaErr) {
+		t.Fatalf("want QuotaExceededError from Close, got %v", err)
+	}
+	// Overwriting with less, or outside the quota, is allowed.
+	if err := d.PutContent(ctx, "/teams/a/new", []byte("12")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/other", []byte("1234567890123")); err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 6}, Quota{Prefix: "/teams/a", Limit: 10, Usage: 6})
+
+	// Move and Delete release usage.
+	if err := d.Move(ctx, "/teams/a/old", "/teams/b/old"); err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 6}, Quota{Prefix: "/teams/a", Limit: 10, Usage: 2})
+	if err := d.Delete(ctx, "/teams/b"); err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 2}, Quota{Prefix: "/teams/a", Limit: 10, Usage: 2})
+
+	// The quotas are persisted with the tree.
+	d, err = New(ctx, common.HexToAddress("0xabcd"), s, false, WithSigner(beecrypto.NewDefaultSigner(pk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 2}, Quota{Prefix: "/teams/a", Limit: 10, Usage: 2})
+	follower, err := NewFollower(ctx, d.Owner(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := follower.Quotas(ctx); err != nil || len(got) != 2 {
+		t.Fatalf("follower quotas: got %+v, %v", got, err)
+	}
+
+	// Usage that drifted from the tree is recomputed.
+	d.quotas["/teams"] = Quota{Prefix: "/teams", Limit: 20, Usage: 17}
+	if _, err := d.RecomputeQuotas(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 2}, Quota{Prefix: "/teams/a", Limit: 10, Usage: 2})
+
+	// So is usage after Fsck repaired the tree.
+	d.quotas["/teams"] = Quota{Prefix: "/teams", Limit: 20, Usage: 17}
+	if _, err := d.Fsck(ctx, FsckOptions{Repair: true}); err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 2}, Quota{Prefix: "/teams/a", Limit: 10, Usage: 2})
+
+	// A zero limit removes the quota.
+	if _, err := d.SetQuota(ctx, "/teams/a", 0); err != nil {
+		t.Fatal(err)
+	}
+	checkQuotas(Quota{Prefix: "/teams", Limit: 20, Usage: 2})
+}
@@ -0,0 +1,190 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/file/joiner"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// RecoverResult is the result of RecoverPaths.
+type RecoverResult struct {
+	Rebuilt []string `json:"rebuilt"` // Paths whose metadata was reconstructed.
+	Linked  []string `json:"linked"`  // Paths added to the entries of their directory.
+	Missing []string `json:"missing"` // Candidates with neither metadata nor data.
+}
+
+// RecoverPaths makes the candidate paths visible again after directory
+// metadata was lost, for example when the last update of a parent's
+// metadata feed never made it to the store. Candidates typically come from
+// an old registry index or from AuditLog.
+//
+// The feeds of every candidate are probed. A candidate with metadata is kept
+// as is; one with only data gets file metadata whose size is the length of
+// the data and whose modification time is the time of recovery. Every
+// ancestor is then given metadata if it has none and an entry for the
+// candidate if it lacks one. Entries already listed are never removed, so
+// RecoverPaths can be run again with more candidates. Empty files cannot be
+// told apart from deleted ones and are reported as missing. With a pinner
+// the whole tree is walked afterwards to bring the pins up to date.
+func (d *swarmDriver) RecoverPaths(ctx context.Context, paths []string) (RecoverResult, error) {
+	result := RecoverResult{Rebuilt: []string{}, Linked: []string{}, Missing: []string{}}
+	if err := d.acquire(); err != nil {
+		return result, err
+	}
+	defer d.release()
+	if d.readOnly {
+		return result, ErrReadOnly
+	}
+	for _, path := range paths {
+		if err := isValidPath(path); err != nil {
+			return result, fmt.Errorf("RecoverPaths: %w", err)
+		}
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	logger.Debug("RecoverPaths Hit", slog.Int("paths", len(paths)))
+
+	// Probe the candidates and collect the entries every directory needs.
+	files := make(map[string]metaData)
+	entries := make(map[string][]string)
+	for _, path := range paths {
+		path = filepath.ToSlash(filepath.Clean(path))
+		if path == "/" {
+			continue
+		}
+		if _, ok := files[path]; ok {
+			continue
+		}
+		meta, err := d.getMetadata(ctx, path)
+		switch {
+		case err == nil:
+		case errors.Is(err, lookuper.ErrNotFound):
+			size, err := d.dataSize(ctx, path)
+			if err != nil {
+				return result, fmt.Errorf("RecoverPaths: %w", err)
+			}
+			if size < 0 {
+				result.Missing = append(result.Missing, path)
+				continue
+			}
+			meta = metaData{Path: path, ModTime: time.Now().Unix(), Size: int(size)}
+			result.Rebuilt = append(result.Rebuilt, path)
+		default:
+			return result, fmt.Errorf("RecoverPaths: failed to get metadata of %s: %w", path, err)
+		}
+		files[path] = meta
+		for child := path; child != "/"; child = filepath.ToSlash(filepath.Dir(child)) {
+			parent := filepath.ToSlash(filepath.Dir(child))
+			entries[parent] = append(entries[parent], filepath.Base(child))
+		}
+	}
+
+	// Plan the directories from the root down before writing anything, so
+	// that a candidate below a file leaves the tree untouched.
+	dirs := make([]string, 0, len(entries))
+	for dir := range entries {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if di, dj := pathDepth(dirs[i]), pathDepth(dirs[j]); di != dj {
+			return di < dj
+		}
+		return dirs[i] < dirs[j]
+	})
+	changed := make(map[string]metaData)
+	for _, dir := range dirs {
+		meta, err := d.getMetadata(ctx, dir)
+		switch {
+		case err == nil:
+			if !meta.IsDir {
+				return result, fmt.Errorf("RecoverPaths: %s is a file", dir)
+			}
+		case errors.Is(err, lookuper.ErrNotFound):
+			if m, ok := files[dir]; ok && !m.IsDir {
+				return result, fmt.Errorf("RecoverPaths: %s is a file", dir)
+			}
+			meta = metaData{IsDir: true, Path: dir, ModTime: time.Now().Unix(), Children: []string{}}
+			changed[dir] = meta
+			result.Rebuilt = append(result.Rebuilt, dir)
+		default:
+			return result, fmt.Errorf("RecoverPaths: failed to get metadata of %s: %w", dir, err)
+		}
+		listed := make(map[string]bool, len(meta.Children))
+		for _, child := range meta.Children {
+			listed[child] = true
+		}
+		for _, child := range entries[dir] {
+			if listed[child] {
+				continue
+			}
+			listed[child] = true
+			meta.Children = append(meta.Children, child)
+			meta.ModTime = time.Now().Unix()
+			changed[dir] = meta
+			result.Linked = append(result.Linked, filepath.ToSlash(filepath.Join(dir, child)))
+		}
+		// A rebuilt directory replaces the candidate entry of the same path.
+		delete(files, dir)
+	}
+
+	// Publish from the root down, so putMetadata finds every parent entry
+	// already in place.
+	for _, dir := range dirs {
+		if meta, ok := changed[dir]; ok {
+			if err := d.putMetadata(ctx, dir, meta); err != nil {
+				return result, fmt.Errorf("RecoverPaths: %w", err)
+			}
+		}
+	}
+	for _, path := range result.Rebuilt {
+		meta, ok := files[path]
+		if !ok {
+			continue
+		}
+		if err := d.putMetadata(ctx, path, meta); err != nil {
+			return result, fmt.Errorf("RecoverPaths: %w", err)
+		}
+	}
+	if err := d.pins.sync(ctx, d); err != nil {
+		return result, fmt.Errorf("RecoverPaths: %w", err)
+	}
+	d.stats.invalidate()
+	if err := d.recomputeQuotas(ctx); err != nil {
+		return result, fmt.Errorf("RecoverPaths: %w", err)
+	}
+	logger.Debug("RecoverPaths: Done", slog.Int("rebuilt", len(result.Rebuilt)), slog.Int("linked", len(result.Linked)))
+	return result, nil
+}
+
+// dataSize returns the length of the data of path, -1 if it has none.
+func (d *swarmDriver) dataSize(ctx context.Context, path string) (int64, error) {
+	ref, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
+	if errors.Is(err, lookuper.ErrNotFound) || (err == nil && isZeroAddress(ref)) {
+		return -1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to lookup data of %s: %w", path, err)
+	}
+	_, size, err := joiner.New(ctx, d.store, ref)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read data of %s: %w", path, err)
+	}
+	return size, nil
+}
+
+// pathDepth is the number of elements in path, zero for the root.
+func pathDepth(path string) int {
+	if path == "/" {
+		return 0
+	}
+	return strings.Count(path, "/")
+}
@@ -0,0 +1,90 @@
+package swarmdriver
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestRecoverPaths(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/a/b/c", "/a/d", "/e"} {
+		if err := d.PutContent(ctx, path, []byte("content of "+path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Lose the entries of /a and the metadata of /a/b and /a/b/c.
+	if err := d.putMetadata(ctx, "/a", metaData{IsDir: true, Path: "/a", Children: []string{}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/a/b", "/a/b/c"} {
+		if err := d.deleteMetadata(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, err := d.List(ctx, "/a"); err != nil || len(got) != 0 {
+		t.Fatalf("List before recovery: got %v, %v", got, err)
+	}
+
+	result, err := d.RecoverPaths(ctx, []string{"/a/b/c", "/a/d", "/e", "/missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(result.Rebuilt)
+	sort.Strings(result.Linked)
+	want := RecoverResult{
+		Rebuilt: []string{"/a/b", "/a/b/c"},
+		Linked:  []string{"/a/b", "/a/b/c", "/a/d"},
+		Missing: []string{"/missing"},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Fatalf("want %+v, got %+v", want, result)
+	}
+	got, err := d.List(ctx, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"/a/b", "/a/d"}) {
+		t.Fatalf("List after recovery: got %v", got)
+	}
+	content, err := d.GetContent(ctx, "/a/b/c")
+	if err != nil || string(content) != "content of /a/b/c" {
+		t.Fatalf("GetContent: got %q, %v", content, err)
+	}
+	fi, err := d.Stat(ctx, "/a/b/c")
+	if err != nil || fi.Size() != int64(len(content)) {
+		t.Fatalf("Stat: got %+v, %v", fi, err)
+	}
+	report, err := d.Fsck(ctx, FsckOptions{})
+	if err != nil || len(report.Issues) != 0 {
+		t.Fatalf("Fsck after recovery: got %+v, %v", report.Issues, err)
+	}
+
+	// Recovering again changes nothing.
+	result, err = d.RecoverPaths(ctx, []string{"/a/b/c", "/a/d"})
+	if err != nil || len(result.Rebuilt)+len(result.Linked)+len(result.Missing) != 0 {
+		t.Fatalf("second recovery: got %+v, %v", result, err)
+	}
+	// A candidate below a file is refused.
+	ref, err := d.split(ctx, []byte("below a file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.publisher.Put(ctx, "/e/f/data", time.Now().Unix(), ref); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.RecoverPaths(ctx, []string{"/e/f"}); err == nil {
+		t.Fatal("want an error for a candidate below a file")
+	}
+}
@@ -0,0 +1,234 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/file/joiner"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+// ReplicateProgress reports the state of a running replication.
+type ReplicateProgress struct {
+	Path          string // Path that was just processed.
+	Copied        int    // Paths whose feeds were republished in the target.
+	Skipped       int    // Paths whose references already matched in the target, a directory counting once for its subtree.
+	ChunksCopied  int    // Chunks written to the target store.
+	ChunksPresent int    // Chunks the target store already held.
+}
+
+// ReplicateOptions configures Replicate.
+type ReplicateOptions struct {
+	// Progress, if set, is called after every path. Both drivers are locked
+	// while it runs, so it must not use them.
+	Progress func(ReplicateProgress)
+}
+
+// Replicate mirrors the tree read by src into dst. Every chunk behind a
+// metadata or data reference that dst's store lacks is copied from src's
+// store, and the feeds are republished under dst's signer, so src may be any
+// driver, view or follower while dst must be writable.
+//
+// Replication is incremental: paths whose references already match in dst
+// are skipped without touching their chunks, and since a directory records
+// the references of its children, a matching directory is skipped with its
+// whole subtree. Directories are published after their children, so an
+// interrupted replication leaves the directories it did not finish behind in
+// dst and resumes by running it again, descending only into those.
+// Directories written before child references were recorded are always
+// descended. Paths that exist only in dst become unreachable once their
+// parent directory is replicated. If dst has a pinner its whole tree is
+// walked afterwards to bring the pins up to date.
+func Replicate(ctx context.Context, src, dst *swarmDriver, opts ReplicateOptions) (ReplicateProgress, error) {
+	var progress ReplicateProgress
+	if err := src.acquire(); err != nil {
+		return progress, fmt.Errorf("Replicate: source: %w", err)
+	}
+	defer src.release()
+	if err := dst.acquire(); err != nil {
+		return progress, fmt.Errorf("Replicate: target: %w", err)
+	}
+	defer dst.release()
+	if dst.readOnly {
+		return progress, fmt.Errorf("Replicate: target: %w", ErrReadOnly)
+	}
+	// Views read through the driver they were derived from, whose mutex
+	// would be taken twice.
+	for d := src; d != nil; d = d.base {
+		if d == dst {
+			return progress, fmt.Errorf("Replicate: source and target are the same driver")
+		}
+	}
+	src.mutex.RLock()
+	defer src.mutex.RUnlock()
+	dst.mutex.Lock()
+	defer dst.mutex.Unlock()
+	logger.Debug("Replicate Hit")
+	r := &replication{src: src, dst: dst, report: opts.Progress}
+	root, err := src.resolveNode(ctx, "/")
+	if err == nil {
+		err = r.replicate(ctx, root)
+	}
+	progress = r.progress
+	if err != nil {
+		return progress, fmt.Errorf("Replicate: %w", err)
+	}
+	if err := dst.pins.sync(ctx, dst); err != nil {
+		return progress, fmt.Errorf("Replicate: %w", err)
+	}
+	dst.stats.invalidate()
+	if err := dst.recomputeQuotas(ctx); err != nil {
+		return progress, fmt.Errorf("Replicate: %w", err)
+	}
+	logger.Debug("Replicate: Success!", slog.Int("copied", progress.Copied), slog.Int("skipped", progress.Skipped))
+	return progress, nil
+}
+
+// replication holds the state of a single Replicate.
+type replication struct {
+	src, dst *swarmDriver
+	report   func(ReplicateProgress)
+	mu       sync.Mutex // Guards progress, chunks are iterated concurrently.
+	progress ReplicateProgress
+}
+
+// replicate brings node and its descendants up to date in the target,
+// children before their parent.
+func (r *replication) replicate(ctx context.Context, node treeNode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	have, err := r.dst.resolveNode(ctx, node.Path)
+	if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+		return err
+	}
+	sameMeta := err == nil && have.MetaRef.Equal(node.MetaRef)
+	sameData := node.Meta.IsDir || (err == nil && have.DataRef.Equal(node.DataRef))
+	if sameMeta && sameData && (!node.Meta.IsDir || node.Meta.Refs != nil) {
+		r.done(node.Path, false)
+		return nil
+	}
+	if node.Meta.IsDir {
+		for _, child := range node.Meta.Children {
+			childNode, err := r.src.resolveNode(ctx, filepath.Join(node.Path, child))
+			if errors.Is(err, lookuper.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if err := r.replicate(ctx, childNode); err != nil {
+				return err
+			}
+		}
+	}
+	if sameMeta && sameData {
+		r.done(node.Path, false)
+		return nil
+	}
+	if err := r.publish(ctx, node, sameMeta, sameData); err != nil {
+		return err
+	}
+	r.done(node.Path, true)
+	return nil
+}
+
+// done counts path as copied or skipped and reports the progress.
+func (r *replication) done(path string, copied bool) {
+	r.mu.Lock()
+	if copied {
+		r.progress.Copied++
+	} else {
+		r.progress.Skipped++
+	}
+	r.progress.Path = path
+	progress := r.progress
+	r.mu.Unlock()
+	if r.report != nil {
+		r.report(progress)
+	}
+}
+
+// publish copies the chunks of node to the target and republishes the feeds
+// whose references differ there.
+func (r *replication) publish(ctx context.Context, node treeNode, sameMeta, sameData bool) error {
+	now := time.Now().Unix()
+	// Publish data before metadata, so the path never appears without it.
+	if !sameData {
+		// Empty files publish the zero address like putData does.
+		ref := swarm.ZeroAddress
+		if !node.DataRef.IsZero() {
+			ref = node.DataRef
+			if err := r.copyChunks(ctx, ref); err != nil {
+				return fmt.Errorf("failed to copy data of %s: %w", node.Path, err)
+			}
+		}
+		if err := r.dst.publisher.Put(ctx, filepath.Join(node.Path, "data"), now, ref); err != nil {
+			return fmt.Errorf("failed to publish data of %s: %w", node.Path, err)
+		}
+	}
+	if !sameMeta {
+		if err := r.copyChunks(ctx, node.MetaRef); err != nil {
+			return fmt.Errorf("failed to copy metadata of %s: %w", node.Path, err)
+		}
+		if err := r.dst.publisher.Put(ctx, filepath.Join(node.Path, "mtdt"), now, node.MetaRef); err != nil {
+			return fmt.Errorf("failed to publish metadata of %s: %w", node.Path, err)
+		}
+	}
+	return nil
+}
+
+// copyChunks copies every chunk of the file at ref that the target store
+// lacks.
+func (r *replication) copyChunks(ctx context.Context, ref swarm.Address) error {
+	j, _, err := joiner.New(ctx, r.src.store, ref)
+	if err != nil {
+		return fmt.Errorf("failed to create joiner: %w", err)
+	}
+	haser, _ := store.AsHaser(r.dst.store)
+	return j.IterateChunkAddresses(func(addr swarm.Address) error {
+		// Encrypted references carry the decryption key after the address.
+		if len(addr.Bytes()) > swarm.HashSize {
+			addr = swarm.NewAddress(addr.Bytes()[:swarm.HashSize])
+		}
+		var err error
+		if haser != nil {
+			// Spare reading the chunk when the target can tell it holds it.
+			var has bool
+			if has, err = haser.Has(ctx, addr); err == nil && !has {
+				err = storage.ErrNotFound
+			}
+		} else {
+			_, err = r.dst.store.Get(ctx, addr)
+		}
+		switch {
+		case err == nil:
+			r.mu.Lock()
+			r.progress.ChunksPresent++
+			r.mu.Unlock()
+			return nil
+		case !errors.Is(err, storage.ErrNotFound):
+			return fmt.Errorf("failed to check chunk %s: %w", addr, err)
+		}
+		ch, err := r.src.store.Get(ctx, addr)
+		if err != nil {
+			return fmt.Errorf("failed to read chunk %s: %w", addr, err)
+		}
+		if err := r.dst.store.Put(ctx, ch); err != nil {
+			return fmt.Errorf("failed to write chunk %s: %w", addr, err)
+		}
+		r.mu.Lock()
+		r.progress.ChunksCopied++
+		r.mu.Unlock()
+		return nil
+	})
+}
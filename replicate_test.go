@@ -0,0 +1,114 @@
+package swarmdriver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestReplicate(t *testing.T) {
+	ctx := context.Background()
+	for _, encrypt := range []bool{false, true} {
+		src, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), encrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), encrypt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		big := bytes.Repeat([]byte("0123456789"), 1000)
+		for path, content := range map[string][]byte{
+			"/repo/a":       []byte("a1"),
+			"/repo/blobs/b": big,
+			"/repo/empty":   {},
+		} {
+			if err := src.PutContent(ctx, path, content); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var calls int
+		progress, err := Replicate(ctx, src, dst, ReplicateOptions{Progress: func(ReplicateProgress) { calls++ }})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if progress.Copied == 0 || progress.ChunksCopied == 0 || calls != progress.Copied+progress.Skipped {
+			t.Fatalf("encrypt %v: unexpected progress %+v after %d calls", encrypt, progress, calls)
+		}
+		if content, err := dst.GetContent(ctx, "/repo/blobs/b"); err != nil || !bytes.Equal(content, big) {
+			t.Fatalf("encrypt %v: target content differs, %v", encrypt, err)
+		}
+		if content, err := dst.GetContent(ctx, "/repo/empty"); err != nil || len(content) != 0 {
+			t.Fatalf("encrypt %v: want empty content, got %q, %v", encrypt, content, err)
+		}
+
+		// A second run only republishes what changed: the file and the
+		// directories above it.
+		if err := src.PutContent(ctx, "/repo/a", []byte("a2")); err != nil {
+			t.Fatal(err)
+		}
+		progress, err = Replicate(ctx, src, dst, ReplicateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The unchanged blobs directory is skipped with its subtree.
+		if progress.Copied != 3 || progress.Skipped != 2 {
+			t.Fatalf("encrypt %v: want 3 paths copied and 2 skipped, got %+v", encrypt, progress)
+		}
+		if content, err := dst.GetContent(ctx, "/repo/a"); err != nil || string(content) != "a2" {
+			t.Fatalf("encrypt %v: want a2, got %q, %v", encrypt, content, err)
+		}
+	}
+}
+
+func TestReplicateResume(t *testing.T) {
+	ctx := context.Background()
+	src, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/x/1", "/x/2", "/y/1"} {
+		if err := src.PutContent(ctx, path, []byte(path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Interrupt the replication once /x is complete.
+	cctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	_, err = Replicate(cctx, src, dst, ReplicateOptions{Progress: func(p ReplicateProgress) {
+		if p.Path == "/x" {
+			cancel()
+		}
+	}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+	progress, err := Replicate(ctx, src, dst, ReplicateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// /x is skipped with its subtree, /y/1, /y and the root are copied.
+	if progress.Copied != 3 || progress.Skipped != 1 {
+		t.Fatalf("want 3 paths copied and 1 skipped, got %+v", progress)
+	}
+	if content, err := dst.GetContent(ctx, "/x/2"); err != nil || string(content) != "/x/2" {
+		t.Fatalf("want /x/2, got %q, %v", content, err)
+	}
+
+	// A view of the target cannot be replicated into it.
+	if _, err := Replicate(ctx, dst.At(time.Now()), dst, ReplicateOptions{}); err == nil {
+		t.Fatal("want error replicating a view into its driver")
+	}
+}
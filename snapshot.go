@@ -0,0 +1,376 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// snapshotIndexFeed is the feed holding the reference of the snapshot index.
+// Tree feeds always start with "/", so the id cannot collide with a path.
+const snapshotIndexFeed = "swarmdriver/snapshots"
+
+var (
+	// ErrSnapshotNotFound is returned for an unknown snapshot name.
+	ErrSnapshotNotFound = errors.New("swarmdriver: snapshot not found")
+	// ErrSnapshotExists is returned when creating a snapshot under a taken name.
+	ErrSnapshotExists = errors.New("swarmdriver: snapshot already exists")
+)
+
+// SnapshotEntry records the references a path resolved to when a snapshot
+// was taken.
+type SnapshotEntry struct {
+	Meta swarm.Address `json:"meta"`           // Reference of the path's metadata.
+	Data swarm.Address `json:"data,omitempty"` // Reference of the path's data, zero for directories and empty files.
+}
+
+// Snapshot is the content of a named snapshot: the references of every path
+// reachable from the root at the time it was taken.
+type Snapshot struct {
+	Name    string                   `json:"name"`
+	Created time.Time                `json:"created"`
+	Entries map[string]SnapshotEntry `json:"entries"`
+}
+
+// SnapshotInfo describes a snapshot in the snapshot index.
+type SnapshotInfo struct {
+	Name      string        `json:"name"`
+	Created   time.Time     `json:"created"`
+	Reference swarm.Address `json:"reference"` // Reference of the stored Snapshot document.
+	Paths     int           `json:"paths"`     // Number of paths captured.
+}
+
+// captureTree records the references of every path reachable from the root.
+func (d *swarmDriver) captureTree(ctx context.Context) (map[string]SnapshotEntry, error) {
+	entries := make(map[string]SnapshotEntry)
+	err := d.walkTree(ctx, "/", func(node treeNode) error {
+		entries[node.Path] = SnapshotEntry{Meta: node.MetaRef, Data: node.DataRef}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// loadSnapshotIndex reads the snapshot index, which is empty until the first
+// snapshot is created.
+func (d *swarmDriver) loadSnapshotIndex(ctx context.Context) ([]SnapshotInfo, error) {
+	ref, err := d.lookuper.Get(ctx, snapshotIndexFeed, time.Now().Unix())
+	if errors.Is(err, lookuper.ErrNotFound) || (err == nil && isZeroAddress(ref)) {
+		return []SnapshotInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup snapshot index: %w", err)
+	}
+	var index []SnapshotInfo
+	if err := d.getJSON(ctx, ref, &index); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot index: %w", err)
+	}
+	return index, nil
+}
+
+// storeSnapshotIndex writes the snapshot index and publishes it.
+func (d *swarmDriver) storeSnapshotIndex(ctx context.Context, index []SnapshotInfo) error {
+	ref, err := d.putJSON(ctx, index)
+	if err != nil {
+		return fmt.Errorf("failed to store snapshot index: %w", err)
+	}
+	if err := d.publisher.Put(ctx, snapshotIndexFeed, time.Now().Unix(), ref); err != nil {
+		return fmt.Errorf("failed to publish snapshot index: %w", err)
+	}
+	return nil
+}
+
+// loadSnapshot reads the snapshot called name.
+func (d *swarmDriver) loadSnapshot(ctx context.Context, name string) (*Snapshot, error) {
+	index, err := d.loadSnapshotIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range index {
+		if info.Name != name {
+			continue
+		}
+		snap := &Snapshot{}
+		if err := d.getJSON(ctx, info.Reference, snap); err != nil {
+			return nil, fmt.Errorf("failed to read snapshot %s: %w", name, err)
+		}
+		return snap, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+}
+
+// CreateSnapshot records the current state of the whole tree under name. The
+// snapshot is stored as content-addressed chunks and listed in the snapshot
+// index feed.
+func (d *swarmDriver) CreateSnapshot(ctx context.Context, name string) (SnapshotInfo, error) {
+	if err := d.acquire(); err != nil {
+		return SnapshotInfo{}, err
+	}
+	defer d.release()
+	if d.readOnly {
+		return SnapshotInfo{}, ErrReadOnly
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	logger.Debug("CreateSnapshot Hit", slog.String("name", name))
+	if name == "" || strings.Contains(name, "/") {
+		return SnapshotInfo{}, fmt.Errorf("CreateSnapshot: invalid snapshot name %q", name)
+	}
+	index, err := d.loadSnapshotIndex(ctx)
+	if err != nil {
+		return SnapshotInfo{}, fmt.Errorf("CreateSnapshot: %w", err)
+	}
+	for _, info := range index {
+		if info.Name == name {
+			return SnapshotInfo{}, fmt.Errorf("CreateSnapshot: %w: %s", ErrSnapshotExists, name)
+		}
+	}
+	entries, err := d.captureTree(ctx)
+	if err != nil {
+		return SnapshotInfo{}, fmt.Errorf("CreateSnapshot: failed to capture tree: %w", err)
+	}
+	snap := Snapshot{Name: name, Created: time.Now().UTC(), Entries: entries}
+	ref, err := d.putJSON(ctx, snap)
+	if err != nil {
+		return SnapshotInfo{}, fmt.Errorf("CreateSnapshot: failed to store snapshot: %w", err)
+	}
+	info := SnapshotInfo{Name: name, Created: snap.Created, Reference: ref, Paths: len(entries)}
+	if err := d.storeSnapshotIndex(ctx, append(index, info)); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("CreateSnapshot: %w", err)
+	}
+	logger.Debug("CreateSnapshot: Success!", slog.String("name", name), slog.String("ref", ref.String()))
+	return info, nil
+}
+
+// ListSnapshots returns the snapshots in the order they were created.
+func (d *swarmDriver) ListSnapshots(ctx context.Context) ([]SnapshotInfo, error) {
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	index, err := d.loadSnapshotIndex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ListSnapshots: %w", err)
+	}
+	return index, nil
+}
+
+// InspectSnapshot returns the content of the snapshot called name.
+func (d *swarmDriver) InspectSnapshot(ctx context.Context, name string) (*Snapshot, error) {
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	snap, err := d.loadSnapshot(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("InspectSnapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// DeleteSnapshot removes the snapshot called name from the index.
+func (d *swarmDriver) DeleteSnapshot(ctx context.Context, name string) error {
+	if err := d.acquire(); err != nil {
+		return err
+	}
+	defer d.release()
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	index, err := d.loadSnapshotIndex(ctx)
+	if err != nil {
+		return fmt.Errorf("DeleteSnapshot: %w", err)
+	}
+	for i, info := range index {
+		if info.Name == name {
+			if err := d.storeSnapshotIndex(ctx, append(index[:i:i], index[i+1:]...)); err != nil {
+				return fmt.Errorf("DeleteSnapshot: %w", err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("DeleteSnapshot: %w: %s", ErrSnapshotNotFound, name)
+}
+
+// MountSnapshot returns a read-only view of the tree as recorded in the
+// snapshot called name.
+func (d *swarmDriver) MountSnapshot(ctx context.Context, name string) (*swarmDriver, error) {
+	if err := d.acquire(); err != nil {
+		return nil, err
+	}
+	defer d.release()
+	snap, err := d.loadSnapshot(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("MountSnapshot: %w", err)
+	}
+	return d.view(snapshotLookuper{entries: snap.Entries}), nil
+}
+
+// DiffSnapshot reports how the live tree differs from the snapshot called
+// name. Changes are sorted by path.
+func (d *swarmDriver) DiffSnapshot(ctx context.Context, name string) ([]Change, error) {
+	var changes []Change
+	err := d.Diff(ctx, SnapshotVersion(name), LiveVersion(), func(c Change) error {
+		changes = append(changes, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DiffSnapshot: %w", err)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// RestoreSnapshot rolls the live tree back to the snapshot called name.
+// Paths recorded in the snapshot are republished with their recorded
+// references and paths created afterwards are deleted. Every path that
+// changed is recorded in the audit log as AuditRestore and reported to
+// watchers. With a pinner the whole tree is walked afterwards to bring the
+// pins up to date.
+func (d *swarmDriver) RestoreSnapshot(ctx context.Context, name string) error {
+	if err := d.acquire(); err != nil {
+		return err
+	}
+	defer d.release()
+	if d.readOnly {
+		return ErrReadOnly
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	logger.Debug("RestoreSnapshot Hit", slog.String("name", name))
+	snap, err := d.loadSnapshot(ctx, name)
+	if err != nil {
+		return fmt.Errorf("RestoreSnapshot: %w", err)
+	}
+	live, err := d.captureTree(ctx)
+	if err != nil {
+		return fmt.Errorf("RestoreSnapshot: failed to capture tree: %w", err)
+	}
+	changes := diffEntries(snap.Entries, live)
+	for _, change := range changes {
+		if err := d.publishEntry(ctx, change.Path, snap.Entries[change.Path], live[change.Path]); err != nil {
+			return fmt.Errorf("RestoreSnapshot: %w", err)
+		}
+	}
+	if err := d.pins.sync(ctx, d); err != nil {
+		return fmt.Errorf("RestoreSnapshot: %w", err)
+	}
+	d.stats.invalidate()
+	if err := d.recomputeQuotas(ctx); err != nil {
+		return fmt.Errorf("RestoreSnapshot: %w", err)
+	}
+	// The changes lead from the snapshot to the live tree, which the restore
+	// reverses.
+	for _, change := range changes {
+		d.audit(ctx, AuditRestore, change.Path, "", change.NewData, change.OldData)
+		switch change.Kind {
+		case ChangeAdded:
+			d.notify(EventDelete, change.Path, "", swarm.ZeroAddress)
+		case ChangeRemoved:
+			d.notify(EventCreate, change.Path, "", change.OldData)
+		default:
+			d.notify(EventUpdate, change.Path, "", change.OldData)
+		}
+	}
+	logger.Debug("RestoreSnapshot: Success!", slog.String("name", name))
+	return nil
+}
+
+// publishEntry republishes the feeds of path whose references differ between
+// the wanted and the current entry. A zero wanted entry deletes the path.
+func (d *swarmDriver) publishEntry(ctx context.Context, path string, want, have SnapshotEntry) error {
+	now := time.Now().Unix()
+	if !want.Data.Equal(have.Data) {
+		if err := d.publisher.Put(ctx, filepath.Join(path, "data"), now, want.Data); err != nil {
+			return fmt.Errorf("failed to publish data for path %s: %w", path, err)
+		}
+	}
+	if !want.Meta.Equal(have.Meta) {
+		if err := d.publisher.Put(ctx, filepath.Join(path, "mtdt"), now, want.Meta); err != nil {
+			return fmt.Errorf("failed to publish metadata for path %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// snapshotLookuper resolves feeds from the references recorded in a snapshot.
+type snapshotLookuper struct {
+	entries map[string]SnapshotEntry
+}
+
+func (l snapshotLookuper) Get(ctx context.Context, id string, version int64) (swarm.Address, error) {
+	entry, ok := l.entries[filepath.ToSlash(filepath.Dir(id))]
+	if ok {
+		switch filepath.Base(id) {
+		case "mtdt":
+			return entry.Meta, nil
+		case "data":
+			if !isZeroAddress(entry.Data) {
+				return entry.Data, nil
+			}
+		}
+	}
+	return swarm.ZeroAddress, fmt.Errorf("snapshot lookup id %s: %w", id, lookuper.ErrNotFound)
+}
+
+// ChangeKind classifies a Change.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"    // The path exists only in the newer tree.
+	ChangeRemoved  ChangeKind = "removed"  // The path exists only in the older tree.
+	ChangeModified ChangeKind = "modified" // The path's size, modification time or data differ.
+)
+
+// Change is a difference of a single path between two trees.
+type Change struct {
+	Kind       ChangeKind    `json:"kind"`
+	Path       string        `json:"path"`
+	IsDir      bool          `json:"isDir"`
+	OldSize    int64         `json:"oldSize,omitempty"`
+	NewSize    int64         `json:"newSize,omitempty"`
+	OldModTime time.Time     `json:"oldModTime,omitempty"`
+	NewModTime time.Time     `json:"newModTime,omitempty"`
+	OldMeta    swarm.Address `json:"oldMeta,omitempty"`
+	NewMeta    swarm.Address `json:"newMeta,omitempty"`
+	OldData    swarm.Address `json:"oldData,omitempty"`
+	NewData    swarm.Address `json:"newData,omitempty"`
+}
+
+// diffEntries compares two captured trees, from older to newer, by reference.
+func diffEntries(older, newer map[string]SnapshotEntry) []Change {
+	var changes []Change
+	for path, o := range older {
+		n, ok := newer[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: ChangeRemoved, Path: path, OldMeta: o.Meta, OldData: o.Data})
+		case !o.Meta.Equal(n.Meta) || !o.Data.Equal(n.Data):
+			changes = append(changes, Change{Kind: ChangeModified, Path: path, OldMeta: o.Meta, NewMeta: n.Meta, OldData: o.Data, NewData: n.Data})
+		}
+	}
+	for path, n := range newer {
+		if _, ok := older[path]; !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, Path: path, NewMeta: n.Meta, NewData: n.Data})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
@@ -0,0 +1,114 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{
+		"/repo/a": "a1",
+		"/repo/b": "b1",
+	} {
+		if err := d.PutContent(ctx, path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.CreateSnapshot(ctx, "release"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.CreateSnapshot(ctx, "release"); !errors.Is(err, ErrSnapshotExists) {
+		t.Fatalf("want ErrSnapshotExists, got %v", err)
+	}
+
+	if err := d.PutContent(ctx, "/repo/a", []byte("a2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(ctx, "/repo/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/c", []byte("c1")); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := d.DiffSnapshot(ctx, "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]ChangeKind{}
+	for _, c := range changes {
+		kinds[c.Path] = c.Kind
+	}
+	for path, kind := range map[string]ChangeKind{
+		"/repo/a": ChangeModified,
+		"/repo/b": ChangeRemoved,
+		"/repo/c": ChangeAdded,
+	} {
+		if kinds[path] != kind {
+			t.Fatalf("%s: want %s, got %q (changes %+v)", path, kind, kinds[path], changes)
+		}
+	}
+
+	mounted, err := d.MountSnapshot(ctx, "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, err := mounted.GetContent(ctx, "/repo/b"); err != nil || string(content) != "b1" {
+		t.Fatalf("mounted snapshot: want b1, got %q, %v", content, err)
+	}
+
+	events, err := d.Watch(ctx, "/repo", WatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RestoreSnapshot(ctx, "release"); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]EventKind{}
+	for len(events) > 0 {
+		ev := <-events
+		got[ev.Path] = ev.Kind
+	}
+	for path, kind := range map[string]EventKind{
+		"/repo/a": EventUpdate,
+		"/repo/b": EventCreate,
+		"/repo/c": EventDelete,
+	} {
+		if got[path] != kind {
+			t.Fatalf("%s: want %s event, got %q (events %v)", path, kind, got[path], got)
+		}
+	}
+	entries, err := d.AuditLog(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := map[string]bool{}
+	for _, e := range entries {
+		if e.Op == AuditRestore {
+			restored[e.Path] = true
+		}
+	}
+	if !restored["/repo/a"] || !restored["/repo/b"] || !restored["/repo/c"] {
+		t.Fatalf("want the restored paths audited, got %v", restored)
+	}
+	if content, err := d.GetContent(ctx, "/repo/a"); err != nil || string(content) != "a1" {
+		t.Fatalf("restored: want a1, got %q, %v", content, err)
+	}
+	if _, err := d.GetContent(ctx, "/repo/c"); !errors.As(err, new(storagedriver.PathNotFoundError)) {
+		t.Fatalf("restored: want /repo/c removed, got %v", err)
+	}
+	if changes, err := d.DiffSnapshot(ctx, "release"); err != nil || len(changes) != 0 {
+		t.Fatalf("restored: want no changes, got %+v, %v", changes, err)
+	}
+}
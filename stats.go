@@ -0,0 +1,280 @@
+package swarmdriver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethersphere/bee/pkg/file/joiner"
+	"github.com/ethersphere/bee/pkg/swarm"
+)
+
+// PrefixStats is the logical size of the files at or below a prefix.
+type PrefixStats struct {
+	Prefix       string `json:"prefix"`
+	Files        int    `json:"files"`
+	LogicalBytes int64  `json:"logicalBytes"` // Sum of the sizes recorded in file metadata.
+}
+
+// RefStats is the physical footprint of a data reference.
+type RefStats struct {
+	Ref    swarm.Address `json:"ref"`
+	Chunks int           `json:"chunks"` // Chunks of the reference, intermediate chunks included.
+	Bytes  int64         `json:"bytes"`  // Stored size of those chunks, spans included.
+	Paths  []string      `json:"paths"`  // Paths below the prefix linking the reference.
+}
+
+// Stats is the result of Stats.
+type Stats struct {
+	PrefixStats
+	Children       []PrefixStats `json:"children"`       // Every entry directly below the prefix.
+	Refs           []RefStats    `json:"refs"`           // Data references below the prefix, sorted.
+	PhysicalChunks int           `json:"physicalChunks"` // Chunks of Refs, each reference counted once.
+	PhysicalBytes  int64         `json:"physicalBytes"`  // Bytes of Refs, each reference counted once.
+	LinkedBytes    int64         `json:"linkedBytes"`    // Bytes of Refs, counted once per linking path.
+	DedupRatio     float64       `json:"dedupRatio"`     // LinkedBytes over PhysicalBytes, 1 without sharing.
+}
+
+// fileStat is the size and data reference of a file.
+type fileStat struct {
+	size int64
+	ref  swarm.Address // Zero for empty files.
+}
+
+// statsCache keeps what Stats needs between calls. The footprint of a data
+// reference never changes, so refs only grows. files mirrors the tree and is
+// kept up to date by the mutating methods of writable drivers; read-only
+// drivers cannot see the writes of the tree's owner and rebuild it every time.
+// It has a mutex of its own because Stats fills it under the driver's read
+// lock.
+type statsCache struct {
+	mu      sync.Mutex
+	tracked bool                // Mutations update files, so it can be kept.
+	files   map[string]fileStat // Every file of the tree, nil until walked.
+	refs    map[string]RefStats // Footprint by data reference byte string, Paths unset.
+}
+
+func newStatsCache(tracked bool) *statsCache {
+	return &statsCache{tracked: tracked, refs: make(map[string]RefStats)}
+}
+
+// set records the size and data reference of the file at path.
+func (s *statsCache) set(path string, size int64, ref swarm.Address) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.files == nil {
+		return
+	}
+	if isZeroAddress(ref) {
+		ref = swarm.ZeroAddress
+	}
+	s.files[filepath.ToSlash(filepath.Clean(path))] = fileStat{size: size, ref: ref}
+}
+
+// drop forgets path and everything below it.
+func (s *statsCache) drop(path string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path = filepath.ToSlash(filepath.Clean(path))
+	for p := range s.files {
+		if underPrefix(p, path) {
+			delete(s.files, p)
+		}
+	}
+}
+
+// move renames path and everything below it to dest, dropping what dest held.
+func (s *statsCache) move(path, dest string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path = filepath.ToSlash(filepath.Clean(path))
+	dest = filepath.ToSlash(filepath.Clean(dest))
+	moved := make(map[string]fileStat)
+	for p, f := range s.files {
+		if underPrefix(p, path) {
+			moved[dest+strings.TrimPrefix(p, path)] = f
+			delete(s.files, p)
+		}
+	}
+	for p := range s.files {
+		if underPrefix(p, dest) {
+			delete(s.files, p)
+		}
+	}
+	for p, f := range moved {
+		s.files[p] = f
+	}
+}
+
+// invalidate makes the next Stats walk the tree again. It is used after
+// operations that change many paths at once.
+func (s *statsCache) invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = nil
+}
+
+// treeFiles returns the files of the tree, walking it unless they are cached.
+func (d *swarmDriver) treeFiles(ctx context.Context, s *statsCache) (map[string]fileStat, error) {
+	s.mu.Lock()
+	files := s.files
+	s.mu.Unlock()
+	if files != nil {
+		return files, nil
+	}
+	files = make(map[string]fileStat)
+	err := d.walkTree(ctx, "/", func(node treeNode) error {
+		if !node.Meta.IsDir {
+			files[node.Path] = fileStat{size: int64(node.Meta.Size), ref: node.DataRef}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk tree: %w", err)
+	}
+	if s.tracked {
+		s.mu.Lock()
+		s.files = files
+		s.mu.Unlock()
+	}
+	return files, nil
+}
+
+// refStats returns the footprint of ref, joining it unless it is cached.
+func (d *swarmDriver) refStats(ctx context.Context, s *statsCache, ref swarm.Address) (RefStats, error) {
+	s.mu.Lock()
+	stats, ok := s.refs[ref.ByteString()]
+	s.mu.Unlock()
+	if ok {
+		return stats, nil
+	}
+	stats = RefStats{Ref: ref}
+	j, _, err := joiner.New(ctx, d.store, ref)
+	if err != nil {
+		return stats, fmt.Errorf("failed to create joiner for %s: %w", ref, err)
+	}
+	err = j.IterateChunkAddresses(func(addr swarm.Address) error {
+		// Encrypted references carry the decryption key after the address.
+		if len(addr.Bytes()) > swarm.HashSize {
+			addr = swarm.NewAddress(addr.Bytes()[:swarm.HashSize])
+		}
+		ch, err := d.store.Get(ctx, addr)
+		if err != nil {
+			return fmt.Errorf("failed to read chunk %s: %w", addr, err)
+		}
+		stats.Chunks++
+		stats.Bytes += int64(len(ch.Data()))
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to size %s: %w", ref, err)
+	}
+	s.mu.Lock()
+	s.refs[ref.ByteString()] = stats
+	s.mu.Unlock()
+	return stats, nil
+}
+
+// Stats reports how much the files at or below prefix use: their logical
+// size from metadata, in total and per entry directly below prefix, and the
+// chunks and bytes stored for every data reference they link. Paths linking
+// the same reference, such as a layer mounted in many repositories, share
+// its chunks; DedupRatio tells how much that saves. References sharing some
+// of their chunks are each counted in full.
+//
+// The footprint of a reference is computed once and cached, and writable
+// drivers keep the sizes and references of the tree up to date as they
+// change it, so repeated calls only read the store for new references.
+func (d *swarmDriver) Stats(ctx context.Context, prefix string) (Stats, error) {
+	var stats Stats
+	if err := d.acquire(); err != nil {
+		return stats, err
+	}
+	defer d.release()
+	if prefix != "/" {
+		if err := isValidPath(prefix); err != nil {
+			return stats, fmt.Errorf("Stats: %w", err)
+		}
+	}
+	prefix = filepath.ToSlash(filepath.Clean(prefix))
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	logger.Debug("Stats Hit", slog.String("prefix", prefix))
+	cache := d.stats
+	if cache == nil {
+		cache = newStatsCache(false)
+	}
+	files, err := d.treeFiles(ctx, cache)
+	if err != nil {
+		return stats, fmt.Errorf("Stats: %w", err)
+	}
+	stats.Prefix = prefix
+	stats.Children = []PrefixStats{}
+	stats.Refs = []RefStats{}
+	children := make(map[string]*PrefixStats)
+	refs := make(map[string]*RefStats)
+	for path, f := range files {
+		if !underPrefix(path, prefix) {
+			continue
+		}
+		stats.Files++
+		stats.LogicalBytes += f.size
+		if path != prefix {
+			rest := strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/")
+			child := filepath.ToSlash(filepath.Join(prefix, strings.SplitN(rest, "/", 2)[0]))
+			c, ok := children[child]
+			if !ok {
+				c = &PrefixStats{Prefix: child}
+				children[child] = c
+			}
+			c.Files++
+			c.LogicalBytes += f.size
+		}
+		if f.ref.IsZero() {
+			continue
+		}
+		r, ok := refs[f.ref.ByteString()]
+		if !ok {
+			footprint, err := d.refStats(ctx, cache, f.ref)
+			if err != nil {
+				return stats, fmt.Errorf("Stats: %s: %w", path, err)
+			}
+			r = &footprint
+			refs[f.ref.ByteString()] = r
+		}
+		r.Paths = append(r.Paths, path)
+	}
+	for _, c := range children {
+		stats.Children = append(stats.Children, *c)
+	}
+	sort.Slice(stats.Children, func(i, j int) bool { return stats.Children[i].Prefix < stats.Children[j].Prefix })
+	for _, r := range refs {
+		sort.Strings(r.Paths)
+		stats.Refs = append(stats.Refs, *r)
+		stats.PhysicalChunks += r.Chunks
+		stats.PhysicalBytes += r.Bytes
+		stats.LinkedBytes += r.Bytes * int64(len(r.Paths))
+	}
+	sort.Slice(stats.Refs, func(i, j int) bool { return stats.Refs[i].Ref.String() < stats.Refs[j].Ref.String() })
+	stats.DedupRatio = 1
+	if stats.PhysicalBytes > 0 {
+		stats.DedupRatio = float64(stats.LinkedBytes) / float64(stats.PhysicalBytes)
+	}
+	return stats, nil
+}
@@ -0,0 +1,113 @@
+package swarmdriver
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+// countingStore counts the chunks read from it.
+type countingStore struct {
+	store.PutGetter
+	gets atomic.Int64
+}
+
+func (s *countingStore) Get(ctx context.Context, addr swarm.Address) (swarm.Chunk, error) {
+	s.gets.Add(1)
+	return s.PutGetter.Get(ctx, addr)
+}
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	s := &countingStore{PutGetter: teststore.NewSwarmInMemoryStore()}
+	d, err := New(ctx, common.HexToAddress("0xabcd"), s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The layer spans two data chunks and an intermediate one.
+	layer := bytes.Repeat([]byte("l"), 5000)
+	layerBytes := int64(swarm.SpanSize+swarm.ChunkSize) + int64(swarm.SpanSize+904) + int64(swarm.SpanSize+2*swarm.HashSize)
+	for path, content := range map[string][]byte{
+		"/repos/r1/layer":    layer,
+		"/repos/r2/layer":    layer,
+		"/repos/r1/manifest": []byte("m"),
+		"/other":             []byte("o"),
+	} {
+		if err := d.PutContent(ctx, path, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	node, err := d.resolveNode(ctx, "/repos/r1/layer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerRef := node.DataRef
+	stats, err := d.Stats(ctx, "/repos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Files != 3 || stats.LogicalBytes != 10001 {
+		t.Fatalf("want 3 files of 10001 bytes, got %+v", stats.PrefixStats)
+	}
+	wantChildren := []PrefixStats{
+		{Prefix: "/repos/r1", Files: 2, LogicalBytes: 5001},
+		{Prefix: "/repos/r2", Files: 1, LogicalBytes: 5000},
+	}
+	if !reflect.DeepEqual(stats.Children, wantChildren) {
+		t.Fatalf("want children %+v, got %+v", wantChildren, stats.Children)
+	}
+	if len(stats.Refs) != 2 || stats.PhysicalChunks != 4 || stats.PhysicalBytes != layerBytes+swarm.SpanSize+1 {
+		t.Fatalf("unexpected physical usage %+v", stats)
+	}
+	for _, r := range stats.Refs {
+		if r.Ref.Equal(layerRef) && (r.Chunks != 3 || r.Bytes != layerBytes || len(r.Paths) != 2) {
+			t.Fatalf("unexpected layer usage %+v", r)
+		}
+	}
+	if want := float64(2*layerBytes+swarm.SpanSize+1) / float64(layerBytes+swarm.SpanSize+1); stats.DedupRatio != want {
+		t.Fatalf("want dedup ratio %v, got %v", want, stats.DedupRatio)
+	}
+
+	// After writes that link no new reference, Stats reads nothing.
+	if err := d.Delete(ctx, "/repos/r2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Move(ctx, "/repos/r1/manifest", "/repos/r1/tag"); err != nil {
+		t.Fatal(err)
+	}
+	gets := s.gets.Load()
+	stats, err = d.Stats(ctx, "/repos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.gets.Load() - gets; n != 0 {
+		t.Fatalf("Stats read %d chunks", n)
+	}
+	if stats.Files != 2 || stats.LinkedBytes != stats.PhysicalBytes || stats.DedupRatio != 1 {
+		t.Fatalf("unexpected usage after delete %+v", stats)
+	}
+	if len(stats.Refs) != 2 || len(stats.Refs[0].Paths) != 1 || len(stats.Refs[1].Paths) != 1 {
+		t.Fatalf("unexpected references %+v", stats.Refs)
+	}
+
+	// A follower walks the tree to the same result.
+	follower, err := NewFollower(ctx, d.Owner(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := follower.Stats(ctx, "/repos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, stats) {
+		t.Fatalf("follower: want %+v, got %+v", stats, got)
+	}
+}
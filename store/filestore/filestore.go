@@ -0,0 +1,194 @@
+// Package filestore provides a chunk store keeping every chunk in its own
+// file below a directory.
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+// errEmptyAddress is returned by Put and Delete for a chunk without address,
+// which names no file.
+var errEmptyAddress = errors.New("filestore: empty chunk address")
+
+// Store keeps chunks in files named by their hex address, fanned out over
+// subdirectories named by the first two hex digits.
+type Store struct {
+	dir string
+}
+
+// New opens the store rooted at dir, creating the directory if needed.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("filestore: failed to create %s: %w", dir, err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) path(addr swarm.Address) string {
+	name := addr.String()
+	return filepath.Join(s.dir, name[:2], name)
+}
+
+// Local reports that the chunks are kept on a local disk.
+func (s *Store) Local() bool {
+	return true
+}
+
+// Put stores the chunk. The file is written under a temporary name, synced
+// and renamed, and the directory is synced, so a chunk is either complete or
+// absent after a crash.
+func (s *Store) Put(ctx context.Context, ch swarm.Chunk) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(ch.Address().Bytes()) == 0 {
+		return errEmptyAddress
+	}
+	path := s.path(ch.Address())
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("filestore: failed to create directory: %w", err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("filestore: failed to create chunk file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(ch.Data()); err != nil {
+		f.Close()
+		return fmt.Errorf("filestore: failed to write chunk %s: %w", ch.Address(), err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("filestore: failed to sync chunk %s: %w", ch.Address(), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("filestore: failed to write chunk %s: %w", ch.Address(), err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("filestore: failed to store chunk %s: %w", ch.Address(), err)
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("filestore: failed to store chunk %s: %w", ch.Address(), err)
+	}
+	return nil
+}
+
+// syncDir makes the entries of the directory at path durable.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Get returns the chunk stored at address, or storage.ErrNotFound.
+func (s *Store) Get(ctx context.Context, address swarm.Address) (swarm.Chunk, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(address.Bytes()) == 0 {
+		return nil, storage.ErrNotFound
+	}
+	data, err := os.ReadFile(s.path(address))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, storage.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filestore: failed to read chunk %s: %w", address, err)
+	}
+	return swarm.NewChunk(address, data), nil
+}
+
+// Has reports whether a file holds the chunk at address.
+func (s *Store) Has(ctx context.Context, address swarm.Address) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	if len(address.Bytes()) == 0 {
+		return false, nil
+	}
+	_, err := os.Stat(s.path(address))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("filestore: failed to stat chunk %s: %w", address, err)
+	}
+	return true, nil
+}
+
+// Iterate calls fn for every chunk file matching filter, with its
+// modification time as the time the chunk was stored. Unless filter is
+// store.AnyChunk every file is read to tell its kind.
+func (s *Store) Iterate(ctx context.Context, filter store.ChunkType, fn func(addr swarm.Address, storedAt time.Time) error) error {
+	return filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("filestore: failed to list chunks: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			// Skip directories and temporary files of puts in progress.
+			return nil
+		}
+		addr, err := swarm.ParseHexAddress(entry.Name())
+		if err != nil {
+			return nil
+		}
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("filestore: failed to stat chunk %s: %w", addr, err)
+		}
+		if filter != store.AnyChunk {
+			ch, err := s.Get(ctx, addr)
+			if errors.Is(err, storage.ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !filter.Match(store.TypeOf(ch)) {
+				return nil
+			}
+		}
+		return fn(addr, info.ModTime())
+	})
+}
+
+// Delete removes the file of the chunk at address.
+func (s *Store) Delete(ctx context.Context, address swarm.Address) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(address.Bytes()) == 0 {
+		return errEmptyAddress
+	}
+	err := os.Remove(s.path(address))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("filestore: failed to delete chunk %s: %w", address, err)
+	}
+	return nil
+}
+
+// Close is a no-op, every Put is complete when it returns.
+func (s *Store) Close() error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/cac"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch, err := cac.New([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, ch.Address()); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, got %v", err)
+	}
+	if err := s.Put(ctx, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chunks survive reopening the store.
+	s, err = New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(ctx, ch.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(ch) || !cac.Valid(got) {
+		t.Fatalf("want %s, got %s", ch, got)
+	}
+	if _, err := s.Get(ctx, swarm.RandAddress(t)); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("want ErrNotFound, got %v", err)
+	}
+
+	if has, err := s.Has(ctx, ch.Address()); err != nil || !has {
+		t.Fatalf("Has: got %v, %v", has, err)
+	}
+	var addrs []swarm.Address
+	if err := s.Iterate(ctx, store.ContentChunk, func(addr swarm.Address, storedAt time.Time) error {
+		if storedAt.IsZero() {
+			t.Fatalf("%s: zero storage time", addr)
+		}
+		addrs = append(addrs, addr)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || !addrs[0].Equal(ch.Address()) {
+		t.Fatalf("Iterate: want %s, got %v", ch.Address(), addrs)
+	}
+	if err := s.Iterate(ctx, store.SingleOwnerChunk, func(addr swarm.Address, _ time.Time) error {
+		return fmt.Errorf("%s is not a single-owner chunk", addr)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		// Deleting a missing chunk succeeds.
+		if err := s.Delete(ctx, ch.Address()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Get(ctx, ch.Address()); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("want ErrNotFound after Delete, got %v", err)
+	}
+	if has, err := s.Has(ctx, ch.Address()); err != nil || has {
+		t.Fatalf("Has after Delete: got %v, %v", has, err)
+	}
+}
+
+func TestStoreEmptyAddress(t *testing.T) {
+	ctx := context.Background()
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, swarm.NewChunk(swarm.ZeroAddress, []byte("data"))); !errors.Is(err, errEmptyAddress) {
+		t.Fatalf("Put: want errEmptyAddress, got %v", err)
+	}
+	if err := s.Delete(ctx, swarm.ZeroAddress); !errors.Is(err, errEmptyAddress) {
+		t.Fatalf("Delete: want errEmptyAddress, got %v", err)
+	}
+	if _, err := s.Get(ctx, swarm.ZeroAddress); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("Get: want ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+)
+
+// ErrCircuitOpen is returned while the circuit breaker is rejecting calls
+// because the backend has been failing.
+var ErrCircuitOpen = errors.New("store: circuit breaker open")
+
+// ResilientOptions configures the retry and circuit breaker behaviour of a
+// store created with NewResilientStore. Zero values select the defaults.
+type ResilientOptions struct {
+	MaxAttempts      int              // Attempts per operation, including the first one.
+	BaseDelay        time.Duration    // Backoff before the first retry.
+	MaxDelay         time.Duration    // Upper bound for a single backoff.
+	OpTimeout        time.Duration    // Deadline of a single attempt, bounded by the caller's context.
+	FailureThreshold int              // Consecutive failed operations that open the breaker.
+	OpenTimeout      time.Duration    // Time the breaker stays open before a probe is let through.
+	Retryable        func(error) bool // Classifies errors worth retrying.
+}
+
+func (o *ResilientOptions) setDefaults() {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 4
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = 50 * time.Millisecond
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = 2 * time.Second
+	}
+	if o.OpTimeout <= 0 {
+		o.OpTimeout = 10 * time.Second
+	}
+	if o.FailureThreshold <= 0 {
+		o.FailureThreshold = 5
+	}
+	if o.OpenTimeout <= 0 {
+		o.OpenTimeout = 30 * time.Second
+	}
+	if o.Retryable == nil {
+		o.Retryable = IsRetryable
+	}
+}
+
+// IsRetryable reports whether err is a transient backend failure. Missing and
+// invalid chunks are answers from the backend, not failures, and context
+// errors belong to the caller.
+func IsRetryable(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, storage.ErrNotFound),
+		errors.Is(err, storage.ErrInvalidChunk),
+		errors.Is(err, ErrCircuitOpen),
+		errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+// resilientStore wraps a PutGetter with retries and a circuit breaker.
+type resilientStore struct {
+	PutGetter
+	opts ResilientOptions
+
+	mu        sync.Mutex
+	failures  int       // Consecutive failed operations.
+	openUntil time.Time // Breaker rejects calls until this time.
+	probing   bool      // A half-open probe is in flight.
+}
+
+// NewResilientStore returns a PutGetter that retries retryable errors with
+// jittered exponential backoff and fails fast with ErrCircuitOpen while the
+// backend is down.
+func NewResilientStore(s PutGetter, opts ResilientOptions) PutGetter {
+	opts.setDefaults()
+	return &resilientStore{PutGetter: s, opts: opts}
+}
+
+// Unwrap returns the store being retried.
+func (s *resilientStore) Unwrap() PutGetter {
+	return s.PutGetter
+}
+
+// Put stores the chunk, retrying transient failures.
+func (s *resilientStore) Put(ctx context.Context, ch swarm.Chunk) error {
+	return s.do(ctx, func(ctx context.Context) error {
+		return s.PutGetter.Put(ctx, ch)
+	})
+}
+
+// Get retrieves the chunk, retrying transient failures.
+func (s *resilientStore) Get(ctx context.Context, addr swarm.Address) (swarm.Chunk, error) {
+	var ch swarm.Chunk
+	err := s.do(ctx, func(ctx context.Context) error {
+		var err error
+		ch, err = s.PutGetter.Get(ctx, addr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+// do runs op until it succeeds, fails with an error that is not retryable or
+// runs out of attempts. The breaker is consulted once and told the outcome
+// once, so an operation counts as a single failure however many attempts it
+// took.
+func (s *resilientStore) do(ctx context.Context, op func(context.Context) error) error {
+	if err := s.allow(); err != nil {
+		return err
+	}
+	var err error
+	for attempt := 0; attempt < s.opts.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if werr := s.wait(ctx, attempt); werr != nil {
+				s.abandon()
+				return werr
+			}
+		}
+		opCtx, cancel := context.WithTimeout(ctx, s.opts.OpTimeout)
+		err = op(opCtx)
+		cancel()
+		// The caller gave up, which says nothing about the backend.
+		if ctx.Err() != nil {
+			s.abandon()
+			return ctx.Err()
+		}
+		if !s.opts.Retryable(err) {
+			s.record(nil)
+			return err
+		}
+	}
+	s.record(err)
+	return err
+}
+
+// wait sleeps for the jittered backoff of the given attempt.
+func (s *resilientStore) wait(ctx context.Context, attempt int) error {
+	delay := s.opts.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > s.opts.MaxDelay {
+		delay = s.opts.MaxDelay
+	}
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// allow reports whether the breaker lets a call through. Once the open
+// timeout has passed a single probe is allowed to test the backend.
+func (s *resilientStore) allow() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures < s.opts.FailureThreshold {
+		return nil
+	}
+	if time.Now().Before(s.openUntil) || s.probing {
+		return ErrCircuitOpen
+	}
+	s.probing = true
+	return nil
+}
+
+// abandon ends a call the caller cancelled without counting it either way,
+// so that a later call can probe the backend.
+func (s *resilientStore) abandon() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probing = false
+}
+
+// record updates the breaker with the outcome of a call.
+func (s *resilientStore) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probing = false
+	if err == nil {
+		s.failures = 0
+		return
+	}
+	s.failures++
+	if s.failures >= s.opts.FailureThreshold {
+		s.openUntil = time.Now().Add(s.opts.OpenTimeout)
+	}
+}
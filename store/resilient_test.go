@@ -0,0 +1,138 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/cac"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+var errFlaky = errors.New("connection reset")
+
+// flakyStore fails the first failures calls with errFlaky.
+type flakyStore struct {
+	store.PutGetter
+	failures int32
+	calls    int32
+}
+
+func (s *flakyStore) Get(ctx context.Context, addr swarm.Address) (swarm.Chunk, error) {
+	if atomic.AddInt32(&s.calls, 1) <= s.failures {
+		return nil, errFlaky
+	}
+	return s.PutGetter.Get(ctx, addr)
+}
+
+func TestResilientStoreRetries(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyStore{PutGetter: teststore.NewSwarmInMemoryStore(), failures: 2}
+	s := store.NewResilientStore(inner, store.ResilientOptions{BaseDelay: time.Millisecond})
+
+	ch, err := cac.New([]byte("retry me"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, ch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, ch.Address()); err != nil {
+		t.Fatalf("get after transient failures: %v", err)
+	}
+	if got := atomic.LoadInt32(&inner.calls); got != 3 {
+		t.Fatalf("want 3 calls, got %d", got)
+	}
+
+	// Not found is an answer from the backend and must not be retried.
+	atomic.StoreInt32(&inner.calls, 0)
+	inner.failures = 0
+	if _, err := s.Get(ctx, swarm.RandAddress(t)); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("want storage.ErrNotFound, got %v", err)
+	}
+	if got := atomic.LoadInt32(&inner.calls); got != 1 {
+		t.Fatalf("want 1 call for not found, got %d", got)
+	}
+}
+
+func TestResilientStoreCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	inner := &flakyStore{PutGetter: teststore.NewSwarmInMemoryStore(), failures: 1 << 30}
+	s := store.NewResilientStore(inner, store.ResilientOptions{
+		MaxAttempts:      2,
+		BaseDelay:        time.Millisecond,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Hour,
+	})
+
+	addr := swarm.RandAddress(t)
+	// Every attempt fails, but each operation counts as a single failure.
+	for i := 0; i < 2; i++ {
+		if _, err := s.Get(ctx, addr); !errors.Is(err, errFlaky) {
+			t.Fatalf("want errFlaky, got %v", err)
+		}
+	}
+	calls := atomic.LoadInt32(&inner.calls)
+	if calls != 4 {
+		t.Fatalf("want 4 calls, got %d", calls)
+	}
+	if _, err := s.Get(ctx, addr); !errors.Is(err, store.ErrCircuitOpen) {
+		t.Fatalf("want ErrCircuitOpen, got %v", err)
+	}
+	if got := atomic.LoadInt32(&inner.calls); got != calls {
+		t.Fatalf("open breaker must not reach the backend, calls %d -> %d", calls, got)
+	}
+}
+
+// blockingStore fails every call, or blocks until the caller gives up.
+type blockingStore struct {
+	store.PutGetter
+	block atomic.Bool
+}
+
+func (s *blockingStore) Get(ctx context.Context, addr swarm.Address) (swarm.Chunk, error) {
+	if s.block.Load() {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, errFlaky
+}
+
+func TestResilientStoreCancelledProbe(t *testing.T) {
+	ctx := context.Background()
+	inner := &blockingStore{PutGetter: teststore.NewSwarmInMemoryStore()}
+	s := store.NewResilientStore(inner, store.ResilientOptions{
+		MaxAttempts:      1,
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+	})
+	addr := swarm.RandAddress(t)
+	for i := 0; i < 2; i++ {
+		if _, err := s.Get(ctx, addr); !errors.Is(err, errFlaky) {
+			t.Fatalf("want errFlaky, got %v", err)
+		}
+	}
+	time.Sleep(60 * time.Millisecond)
+
+	// The caller cancels the half-open probe. The backend is still down, so
+	// the failures must be kept and the next failed probe reopen the breaker.
+	inner.block.Store(true)
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Get(cctx, addr); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want context.DeadlineExceeded, got %v", err)
+	}
+	inner.block.Store(false)
+	if _, err := s.Get(ctx, addr); !errors.Is(err, errFlaky) {
+		t.Fatalf("want a new probe, got %v", err)
+	}
+	if _, err := s.Get(ctx, addr); !errors.Is(err, store.ErrCircuitOpen) {
+		t.Fatalf("want ErrCircuitOpen, got %v", err)
+	}
+}
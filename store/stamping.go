@@ -0,0 +1,286 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+
+	"github.com/ethersphere/bee/pkg/crypto"
+	"github.com/ethersphere/bee/pkg/postage"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/storage/inmemstore"
+	"github.com/ethersphere/bee/pkg/swarm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrNoUsableBatch is returned by a stamping store when every batch is
+// expired, about to expire, or too full in the bucket of the chunk.
+var ErrNoUsableBatch = errors.New("store: no usable postage batch")
+
+// StampBatch is a postage batch a stamping store may stamp chunks with.
+type StampBatch struct {
+	// Batch is the batch as bought on chain. Its Value is the normalised
+	// balance compared with the chain state's total amount to tell expiry.
+	Batch *postage.Batch
+	// Issuer holds the bucket counts of earlier uploads. Nil starts a new
+	// issuer with empty buckets, which is only right for an unused batch.
+	Issuer *postage.StampIssuer
+}
+
+// StampingOptions configures a store created with NewStampingStore. Zero
+// values select the defaults.
+type StampingOptions struct {
+	MaxUtilization float64       // Fraction of a bucket a batch may fill, 1 lets it fill completely.
+	MinTTL         uint64        // Blocks a batch must have left to be used.
+	Items          storage.Store // Records the index given to every stamped chunk, in memory by default.
+}
+
+func (o *StampingOptions) setDefaults() {
+	if o.MaxUtilization <= 0 || o.MaxUtilization > 1 {
+		o.MaxUtilization = 1
+	}
+	if o.Items == nil {
+		o.Items = inmemstore.New()
+	}
+}
+
+// BatchCapacity is what is left of a batch of a stamping store.
+type BatchCapacity struct {
+	ID          []byte  `json:"id"`
+	Active      bool    `json:"active"`      // Batch the store stamps with first.
+	Usable      bool    `json:"usable"`      // Not expired and with at least MinTTL blocks left.
+	Utilization float64 `json:"utilization"` // Fill of the fullest bucket, up to 1.
+	Remaining   uint64  `json:"remaining"`   // Chunks that can still be stamped below MaxUtilization.
+	TTL         uint64  `json:"ttl"`         // Blocks until the batch expires, MaxUint64 if unknown.
+}
+
+// stampBatch is a batch with its issuer and a copy of its bucket counts,
+// kept to check a bucket without copying all of them from the issuer.
+type stampBatch struct {
+	batch   *postage.Batch
+	issuer  *postage.StampIssuer
+	stamper postage.Stamper
+	buckets []uint32
+	limit   uint32 // Count a bucket may reach.
+}
+
+// StampingStore wraps a PutGetter and stamps every chunk put through it with
+// a postage batch. It uses one batch until the bucket of a chunk reaches
+// MaxUtilization or the batch is within MinTTL blocks of expiring, then
+// rotates to the next usable batch. It never lets a bucket wrap around, which
+// on a mutable batch would silently drop the oldest chunks of the bucket.
+//
+// StampingStore is a prometheus.Collector exposing the remaining capacity,
+// utilization and TTL of every batch.
+type StampingStore struct {
+	PutGetter
+	signer crypto.Signer
+	chain  postage.ChainStateGetter
+	opts   StampingOptions
+
+	mu      sync.Mutex
+	batches []*stampBatch
+	active  int
+
+	remainingDesc   *prometheus.Desc
+	utilizationDesc *prometheus.Desc
+	ttlDesc         *prometheus.Desc
+}
+
+// NewStampingStore returns a store stamping the chunks put through s with
+// batches, tried in order. Every batch must be owned by signer. chain tells
+// the expiry of the batches; it may be nil, in which case batches never
+// expire.
+func NewStampingStore(s PutGetter, signer crypto.Signer, chain postage.ChainStateGetter, batches []StampBatch, opts StampingOptions) (*StampingStore, error) {
+	opts.setDefaults()
+	if len(batches) == 0 {
+		return nil, fmt.Errorf("store: no postage batches: %w", ErrNoUsableBatch)
+	}
+	owner, err := signer.EthereumAddress()
+	if err != nil {
+		return nil, fmt.Errorf("store: failed to get signer address: %w", err)
+	}
+	st := &StampingStore{
+		PutGetter: s,
+		signer:    signer,
+		chain:     chain,
+		opts:      opts,
+		remainingDesc: prometheus.NewDesc("swarmdriver_postage_remaining_chunks",
+			"Chunks that can still be stamped with the batch.", []string{"batch"}, nil),
+		utilizationDesc: prometheus.NewDesc("swarmdriver_postage_utilization_ratio",
+			"Fill of the fullest bucket of the batch.", []string{"batch"}, nil),
+		ttlDesc: prometheus.NewDesc("swarmdriver_postage_ttl_blocks",
+			"Blocks until the batch expires.", []string{"batch"}, nil),
+	}
+	for _, b := range batches {
+		if b.Batch == nil {
+			return nil, errors.New("store: nil postage batch")
+		}
+		if !bytes.Equal(b.Batch.Owner, owner.Bytes()) {
+			return nil, fmt.Errorf("store: batch %x is not owned by %s", b.Batch.ID, owner)
+		}
+		if b.Batch.BucketDepth >= b.Batch.Depth {
+			return nil, fmt.Errorf("store: batch %x has bucket depth %d not below depth %d", b.Batch.ID, b.Batch.BucketDepth, b.Batch.Depth)
+		}
+		issuer := b.Issuer
+		if issuer == nil {
+			issuer = postage.NewStampIssuer("swarmdriver", "", b.Batch.ID, b.Batch.Value, b.Batch.Depth, b.Batch.BucketDepth, b.Batch.Start, b.Batch.Immutable)
+		} else if !bytes.Equal(issuer.ID(), b.Batch.ID) {
+			return nil, fmt.Errorf("store: issuer of batch %x is for batch %x", b.Batch.ID, issuer.ID())
+		}
+		limit := uint32(math.Floor(float64(issuer.BucketUpperBound()) * opts.MaxUtilization))
+		st.batches = append(st.batches, &stampBatch{
+			batch:   b.Batch,
+			issuer:  issuer,
+			stamper: postage.NewStamper(opts.Items, issuer, signer),
+			buckets: issuer.Buckets(),
+			limit:   limit,
+		})
+	}
+	return st, nil
+}
+
+// Unwrap returns the store receiving the stamped chunks.
+func (s *StampingStore) Unwrap() PutGetter {
+	return s.PutGetter
+}
+
+// Put stamps the chunk and hands it to the underlying store.
+func (s *StampingStore) Put(ctx context.Context, ch swarm.Chunk) error {
+	stamp, err := s.stamp(ch.Address())
+	if err != nil {
+		return err
+	}
+	return s.PutGetter.Put(ctx, ch.WithStamp(stamp))
+}
+
+// stamp stamps addr with the active batch, rotating to the next usable one
+// if the active batch cannot take it.
+func (s *StampingStore) stamp(addr swarm.Address) (*postage.Stamp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.chainState()
+	for i := range s.batches {
+		n := (s.active + i) % len(s.batches)
+		b := s.batches[n]
+		if !s.usable(b, state) || b.buckets[bucketOf(b, addr)] >= b.limit {
+			continue
+		}
+		stamp, err := b.stamper.Stamp(addr)
+		if err != nil {
+			return nil, fmt.Errorf("store: failed to stamp %s with batch %x: %w", addr, b.batch.ID, err)
+		}
+		bucket, index := postage.BucketIndexFromBytes(stamp.Index())
+		if index >= b.buckets[bucket] {
+			b.buckets[bucket] = index + 1
+		}
+		s.active = n
+		return stamp, nil
+	}
+	return nil, fmt.Errorf("store: cannot stamp %s: %w", addr, ErrNoUsableBatch)
+}
+
+// chainState returns the current chain state, nil if it is unknown.
+func (s *StampingStore) chainState() *postage.ChainState {
+	if s.chain == nil {
+		return nil
+	}
+	state := s.chain.GetChainState()
+	if state == nil || state.TotalAmount == nil {
+		return nil
+	}
+	return state
+}
+
+// usable reports whether b is not expired and has at least MinTTL blocks left.
+func (s *StampingStore) usable(b *stampBatch, state *postage.ChainState) bool {
+	if state == nil {
+		return true
+	}
+	if b.batch.Value.Cmp(state.TotalAmount) <= 0 {
+		return false
+	}
+	return ttl(b, state) >= s.opts.MinTTL
+}
+
+// ttl returns the blocks until b expires at the current price.
+func ttl(b *stampBatch, state *postage.ChainState) uint64 {
+	if state == nil || state.CurrentPrice == nil || state.CurrentPrice.Sign() <= 0 {
+		return math.MaxUint64
+	}
+	left := new(big.Int).Sub(b.batch.Value, state.TotalAmount)
+	if left.Sign() <= 0 {
+		return 0
+	}
+	left.Div(left, state.CurrentPrice)
+	if !left.IsUint64() {
+		return math.MaxUint64
+	}
+	return left.Uint64()
+}
+
+// bucketOf returns the collision bucket of addr in b.
+func bucketOf(b *stampBatch, addr swarm.Address) uint32 {
+	return binary.BigEndian.Uint32(addr.Bytes()[:4]) >> (32 - b.batch.BucketDepth)
+}
+
+// Capacity returns what is left of every batch, in the order they are tried.
+func (s *StampingStore) Capacity() []BatchCapacity {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.chainState()
+	capacity := make([]BatchCapacity, 0, len(s.batches))
+	for n, b := range s.batches {
+		c := BatchCapacity{
+			ID:     append([]byte(nil), b.batch.ID...),
+			Active: n == s.active,
+			Usable: s.usable(b, state),
+			TTL:    ttl(b, state),
+		}
+		var fullest uint32
+		for _, count := range b.buckets {
+			fullest = max(fullest, count)
+			if count < b.limit {
+				c.Remaining += uint64(b.limit - count)
+			}
+		}
+		c.Utilization = float64(fullest) / float64(b.issuer.BucketUpperBound())
+		capacity = append(capacity, c)
+	}
+	return capacity
+}
+
+// Issuers returns the issuers of the batches, to be saved and passed back in
+// StampBatch.Issuer when the store is created again.
+func (s *StampingStore) Issuers() []*postage.StampIssuer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issuers := make([]*postage.StampIssuer, len(s.batches))
+	for i, b := range s.batches {
+		issuers[i] = b.issuer
+	}
+	return issuers
+}
+
+// Describe implements prometheus.Collector.
+func (s *StampingStore) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.remainingDesc
+	ch <- s.utilizationDesc
+	ch <- s.ttlDesc
+}
+
+// Collect implements prometheus.Collector.
+func (s *StampingStore) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range s.Capacity() {
+		id := hex.EncodeToString(c.ID)
+		ch <- prometheus.MustNewConstMetric(s.remainingDesc, prometheus.GaugeValue, float64(c.Remaining), id)
+		ch <- prometheus.MustNewConstMetric(s.utilizationDesc, prometheus.GaugeValue, c.Utilization, id)
+		ch <- prometheus.MustNewConstMetric(s.ttlDesc, prometheus.GaugeValue, float64(c.TTL), id)
+	}
+}
@@ -0,0 +1,206 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethersphere/bee/pkg/cac"
+	"github.com/ethersphere/bee/pkg/crypto"
+	"github.com/ethersphere/bee/pkg/postage"
+	postagetesting "github.com/ethersphere/bee/pkg/postage/testing"
+	"github.com/ethersphere/bee/pkg/swarm"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+// fakeChain is a postage.ChainStateGetter returning a state set by the test.
+type fakeChain struct {
+	state *postage.ChainState
+}
+
+func (c *fakeChain) GetChainState() *postage.ChainState {
+	return c.state
+}
+
+// stampRecorder records the stamp of every chunk put.
+type stampRecorder struct {
+	store.PutGetter
+	stamps []swarm.Stamp
+}
+
+func (s *stampRecorder) Put(ctx context.Context, ch swarm.Chunk) error {
+	s.stamps = append(s.stamps, ch.Stamp())
+	return s.PutGetter.Put(ctx, ch)
+}
+
+// newTestBatch returns a batch of 4 buckets of 2 chunks owned by owner.
+func newTestBatch(owner []byte, value int64) store.StampBatch {
+	b := postagetesting.MustNewBatch(postagetesting.WithOwner(owner), postagetesting.WithValue(value), postagetesting.WithDepth(3))
+	b.BucketDepth = 2
+	return store.StampBatch{Batch: b}
+}
+
+func newSigner(t *testing.T) (crypto.Signer, []byte) {
+	t.Helper()
+	key, err := crypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := crypto.NewDefaultSigner(key)
+	owner, err := signer.EthereumAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, owner.Bytes()
+}
+
+func TestStampingStoreRotates(t *testing.T) {
+	ctx := context.Background()
+	signer, owner := newSigner(t)
+	first, second := newTestBatch(owner, 1000), newTestBatch(owner, 1000)
+	inner := &stampRecorder{PutGetter: teststore.NewSwarmInMemoryStore()}
+	s, err := store.NewStampingStore(inner, signer, nil, []store.StampBatch{first, second}, store.StampingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two batches of 8 chunks take at most 16 chunks, fewer if the chunks
+	// do not spread evenly over the buckets.
+	var stored int
+	for i := 0; i < 100; i++ {
+		ch, err := cac.New([]byte{byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.Put(ctx, ch)
+		if errors.Is(err, store.ErrNoUsableBatch) {
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		stored++
+		got, err := inner.Get(ctx, ch.Address())
+		if err != nil || !bytes.Equal(got.Data(), ch.Data()) {
+			t.Fatalf("chunk %d not stored: %v", i, err)
+		}
+	}
+	if stored != 16 {
+		t.Fatalf("stored %d chunks, want 16", stored)
+	}
+	var withFirst int
+	for _, stamp := range inner.stamps {
+		if bytes.Equal(stamp.BatchID(), first.Batch.ID) {
+			withFirst++
+		}
+	}
+	if withFirst != 8 {
+		t.Fatalf("%d chunks stamped with the first batch, want 8", withFirst)
+	}
+	for i, c := range s.Capacity() {
+		if c.Remaining != 0 || c.Utilization != 1 {
+			t.Errorf("batch %d: remaining %d, utilization %v; want 0 and 1", i, c.Remaining, c.Utilization)
+		}
+	}
+}
+
+func TestStampingStoreMaxUtilization(t *testing.T) {
+	ctx := context.Background()
+	signer, owner := newSigner(t)
+	batch := newTestBatch(owner, 1000)
+	s, err := store.NewStampingStore(teststore.NewSwarmInMemoryStore(), signer, nil, []store.StampBatch{batch}, store.StampingOptions{MaxUtilization: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored int
+	for i := 0; i < 100; i++ {
+		ch, err := cac.New([]byte{byte(i)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Put(ctx, ch); err == nil {
+			stored++
+		} else if !errors.Is(err, store.ErrNoUsableBatch) {
+			t.Fatal(err)
+		}
+	}
+	if stored != 4 {
+		t.Fatalf("stored %d chunks, want one per bucket", stored)
+	}
+	if c := s.Capacity()[0]; c.Remaining != 0 || c.Utilization != 0.5 {
+		t.Fatalf("remaining %d, utilization %v; want 0 and 0.5", c.Remaining, c.Utilization)
+	}
+}
+
+func TestStampingStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	signer, owner := newSigner(t)
+	expiring, lasting := newTestBatch(owner, 1000), newTestBatch(owner, 5000)
+	chain := &fakeChain{state: &postage.ChainState{Block: 1, TotalAmount: big.NewInt(900), CurrentPrice: big.NewInt(10)}}
+	inner := &stampRecorder{PutGetter: teststore.NewSwarmInMemoryStore()}
+	s, err := store.NewStampingStore(inner, signer, chain, []store.StampBatch{expiring, lasting}, store.StampingOptions{MinTTL: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first batch has 10 blocks left, fewer than MinTTL.
+	ch, err := cac.New([]byte("expiring"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, ch); err != nil {
+		t.Fatal(err)
+	}
+	if got := inner.stamps[0].BatchID(); !bytes.Equal(got, lasting.Batch.ID) {
+		t.Fatalf("stamped with %x, want the lasting batch", got)
+	}
+	capacity := s.Capacity()
+	if capacity[0].Usable || capacity[0].TTL != 10 || !capacity[1].Usable || !capacity[1].Active {
+		t.Fatalf("unexpected capacity %+v", capacity)
+	}
+
+	chain.state = &postage.ChainState{Block: 2, TotalAmount: big.NewInt(5000), CurrentPrice: big.NewInt(10)}
+	if err := s.Put(ctx, ch); !errors.Is(err, store.ErrNoUsableBatch) {
+		t.Fatalf("got %v, want ErrNoUsableBatch", err)
+	}
+}
+
+func TestStampingStoreOwner(t *testing.T) {
+	signer, _ := newSigner(t)
+	_, other := newSigner(t)
+	_, err := store.NewStampingStore(teststore.NewSwarmInMemoryStore(), signer, nil, []store.StampBatch{newTestBatch(other, 1000)}, store.StampingOptions{})
+	if err == nil {
+		t.Fatal("expected an error for a batch of another owner")
+	}
+}
+
+func TestStampingStoreMetrics(t *testing.T) {
+	signer, owner := newSigner(t)
+	s, err := store.NewStampingStore(teststore.NewSwarmInMemoryStore(), signer, nil, []store.StampBatch{newTestBatch(owner, 1000)}, store.StampingOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(s); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+	}
+	if values["swarmdriver_postage_remaining_chunks"] != 8 {
+		t.Fatalf("unexpected metrics %v", values)
+	}
+	if _, ok := values["swarmdriver_postage_utilization_ratio"]; !ok {
+		t.Fatalf("unexpected metrics %v", values)
+	}
+}
@@ -1,9 +1,14 @@
 package store
 
 import (
+	"context"
 	"io"
+	"time"
 
+	"github.com/ethersphere/bee/pkg/cac"
+	"github.com/ethersphere/bee/pkg/soc"
 	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
 )
 
 type PutGetter interface {
@@ -12,3 +17,131 @@ type PutGetter interface {
 
 	io.Closer
 }
+
+// ChunkType is the kind of a chunk, used to filter Iterate.
+type ChunkType int
+
+const (
+	// AnyChunk matches every chunk.
+	AnyChunk ChunkType = iota
+	// ContentChunk matches content-addressed chunks, which hold file data
+	// and metadata.
+	ContentChunk
+	// SingleOwnerChunk matches single-owner chunks, which hold feed updates.
+	SingleOwnerChunk
+)
+
+// TypeOf returns the kind of ch, AnyChunk if it is neither a valid
+// content-addressed nor a valid single-owner chunk.
+func TypeOf(ch swarm.Chunk) ChunkType {
+	switch {
+	case cac.Valid(ch):
+		return ContentChunk
+	case soc.Valid(ch):
+		return SingleOwnerChunk
+	}
+	return AnyChunk
+}
+
+// Match reports whether a chunk of kind typ passes the filter t.
+func (t ChunkType) Match(typ ChunkType) bool {
+	return t == AnyChunk || t == typ
+}
+
+// Iterator is implemented by stores that can enumerate the chunks they hold.
+type Iterator interface {
+	// Iterate calls fn with the address of every stored chunk of the kind
+	// selected by filter and the time it was stored. Iteration stops at the
+	// first error fn returns, which Iterate returns. Chunks put or deleted
+	// during iteration may or may not be visited.
+	Iterate(ctx context.Context, filter ChunkType, fn func(addr swarm.Address, storedAt time.Time) error) error
+}
+
+// Haser is implemented by stores that can tell whether they hold a chunk
+// without reading it.
+type Haser interface {
+	Has(ctx context.Context, addr swarm.Address) (bool, error)
+}
+
+// Deleter is implemented by stores that can remove chunks.
+type Deleter interface {
+	// Delete removes the chunk at addr. Deleting a missing chunk is not an
+	// error.
+	Delete(ctx context.Context, addr swarm.Address) error
+}
+
+// Pinner is implemented by stores that can protect content from eviction,
+// such as a Swarm node through its pinning API. Pins are not counted: a
+// reference pinned twice is released by a single Unpin. The driver counts the
+// paths linking each reference itself, in memory, and rebuilds the counts
+// from the tree when it is constructed.
+type Pinner interface {
+	// Pin protects every chunk of the file at root.
+	Pin(ctx context.Context, root swarm.Address) error
+	// Unpin releases the protection of the file at root.
+	Unpin(ctx context.Context, root swarm.Address) error
+}
+
+// NopPinner is a Pinner that does nothing, for stores that never evict.
+type NopPinner struct{}
+
+func (NopPinner) Pin(context.Context, swarm.Address) error   { return nil }
+func (NopPinner) Unpin(context.Context, swarm.Address) error { return nil }
+
+// Local is implemented by stores that keep chunks in process memory or on a
+// local disk, where chunks read back are the chunks that were put. Stores
+// that do not implement it, such as clients of a Swarm node, are remote.
+type Local interface {
+	Local() bool
+}
+
+// IsLocal reports whether s, or the first store it wraps that implements
+// Local, keeps its chunks locally.
+func IsLocal(s PutGetter) bool {
+	l, ok := as[Local](s)
+	return ok && l.Local()
+}
+
+// Wrapper is implemented by stores that decorate another store. The
+// extension interfaces are looked up through it by AsIterator, AsHaser,
+// AsDeleter and AsPinner, so they stay available below validating or
+// resilient stores.
+type Wrapper interface {
+	Unwrap() PutGetter
+}
+
+// AsIterator returns s, or the first store it wraps, that implements
+// Iterator.
+func AsIterator(s PutGetter) (Iterator, bool) {
+	return as[Iterator](s)
+}
+
+// AsHaser returns s, or the first store it wraps, that implements Haser.
+func AsHaser(s PutGetter) (Haser, bool) {
+	return as[Haser](s)
+}
+
+// AsDeleter returns s, or the first store it wraps, that implements Deleter.
+func AsDeleter(s PutGetter) (Deleter, bool) {
+	return as[Deleter](s)
+}
+
+// AsPinner returns s, or the first store it wraps, that implements Pinner.
+func AsPinner(s PutGetter) (Pinner, bool) {
+	return as[Pinner](s)
+}
+
+func as[T any](s PutGetter) (T, bool) {
+	for s != nil {
+		if t, ok := s.(T); ok {
+			return t, true
+		}
+		w, ok := s.(Wrapper)
+		if !ok {
+			break
+		}
+		s = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
@@ -0,0 +1,82 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/cac"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+	"github.com/ethersphere/bee/pkg/soc"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestExtensions(t *testing.T) {
+	ctx := context.Background()
+	inner := teststore.NewSwarmInMemoryStore()
+	s := store.NewValidatingStore(store.NewResilientStore(inner, store.ResilientOptions{}))
+
+	ch, err := cac.New([]byte("hello swarm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := beecrypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sch, err := soc.New(make([]byte, swarm.HashSize), ch).Sign(beecrypto.NewDefaultSigner(pk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.TypeOf(ch) != store.ContentChunk || store.TypeOf(sch) != store.SingleOwnerChunk {
+		t.Fatalf("TypeOf: got %d and %d", store.TypeOf(ch), store.TypeOf(sch))
+	}
+	for _, c := range []swarm.Chunk{ch, sch} {
+		if err := s.Put(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The extensions of the in-memory store are found below the wrappers.
+	iterator, ok := store.AsIterator(s)
+	if !ok {
+		t.Fatal("AsIterator: not found")
+	}
+	for filter, want := range map[store.ChunkType]int{store.AnyChunk: 2, store.ContentChunk: 1, store.SingleOwnerChunk: 1} {
+		n := 0
+		if err := iterator.Iterate(ctx, filter, func(swarm.Address, time.Time) error {
+			n++
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if n != want {
+			t.Fatalf("Iterate(%d): want %d chunks, got %d", filter, want, n)
+		}
+	}
+	haser, ok := store.AsHaser(s)
+	if !ok {
+		t.Fatal("AsHaser: not found")
+	}
+	deleter, ok := store.AsDeleter(s)
+	if !ok {
+		t.Fatal("AsDeleter: not found")
+	}
+	if err := deleter.Delete(ctx, ch.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if has, err := haser.Has(ctx, ch.Address()); err != nil || has {
+		t.Fatalf("Has after Delete: got %v, %v", has, err)
+	}
+	if has, err := haser.Has(ctx, sch.Address()); err != nil || !has {
+		t.Fatalf("Has: got %v, %v", has, err)
+	}
+
+	// A store without extensions has none to find.
+	if _, ok := store.AsIterator(store.NewValidatingStore(&flakyStore{PutGetter: inner})); ok {
+		t.Fatal("AsIterator: found an iterator on a plain PutGetter")
+	}
+}
@@ -0,0 +1,67 @@
+package teststore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/ethersphere/bee/pkg/swarm"
+)
+
+// MemoryPinner is a store.Pinner that records the pinned references in
+// memory. Like a Swarm node it does not count pins.
+type MemoryPinner struct {
+	mu     sync.Mutex
+	pinned map[string]swarm.Address
+	calls  int
+}
+
+// NewMemoryPinner returns a MemoryPinner without pins.
+func NewMemoryPinner() *MemoryPinner {
+	return &MemoryPinner{pinned: make(map[string]swarm.Address)}
+}
+
+// Pin records root as pinned.
+func (p *MemoryPinner) Pin(ctx context.Context, root swarm.Address) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pinned[root.ByteString()] = root
+	p.calls++
+	return nil
+}
+
+// Unpin forgets root.
+func (p *MemoryPinner) Unpin(ctx context.Context, root swarm.Address) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pinned, root.ByteString())
+	p.calls++
+	return nil
+}
+
+// Pinned reports whether root is pinned.
+func (p *MemoryPinner) Pinned(root swarm.Address) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.pinned[root.ByteString()]
+	return ok
+}
+
+// Pins returns the pinned references, sorted.
+func (p *MemoryPinner) Pins() []swarm.Address {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pins := make([]swarm.Address, 0, len(p.pinned))
+	for _, root := range p.pinned {
+		pins = append(pins, root)
+	}
+	sort.Slice(pins, func(i, j int) bool { return pins[i].String() < pins[j].String() })
+	return pins
+}
+
+// Calls returns the number of Pin and Unpin calls made.
+func (p *MemoryPinner) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls
+}
@@ -3,24 +3,34 @@ package teststore
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/ethersphere/bee/pkg/storage"
 	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
 )
 
 // SwarmInMemoryStore represents an in-memory key-value store for Swarm.
 type SwarmInMemoryStore struct {
-	data map[string]swarm.Chunk // Change to use string as the key
-	mu   sync.RWMutex
+	data     map[string]swarm.Chunk // Change to use string as the key
+	storedAt map[string]time.Time   // Time each chunk was last put.
+	mu       sync.RWMutex
 }
 
 // NewSwarmInMemoryStore creates a new in-memory key-value store.
 func NewSwarmInMemoryStore() *SwarmInMemoryStore {
 	return &SwarmInMemoryStore{
-		data: make(map[string]swarm.Chunk), // Initialize the map with string keys
+		data:     make(map[string]swarm.Chunk), // Initialize the map with string keys
+		storedAt: make(map[string]time.Time),
 	}
 }
 
+// Local reports that the chunks are kept in memory.
+func (s *SwarmInMemoryStore) Local() bool {
+	return true
+}
+
 // Put stores the given chunk in the store.
 func (s *SwarmInMemoryStore) Put(ctx context.Context, chunk swarm.Chunk) error {
 	s.mu.Lock()
@@ -28,6 +38,7 @@ func (s *SwarmInMemoryStore) Put(ctx context.Context, chunk swarm.Chunk) error {
 
 	key := chunk.Address().String() // Convert the address to a string
 	s.data[key] = chunk
+	s.storedAt[key] = time.Now()
 	return nil
 }
 
@@ -45,6 +56,58 @@ func (s *SwarmInMemoryStore) Get(ctx context.Context, address swarm.Address) (sw
 	return chunk, nil
 }
 
+// Has reports whether a chunk is stored at address.
+func (s *SwarmInMemoryStore) Has(ctx context.Context, address swarm.Address) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.data[address.String()]
+	return exists, nil
+}
+
+// Iterate calls fn for every stored chunk matching filter. The chunks are
+// collected first, so fn may put and delete chunks.
+func (s *SwarmInMemoryStore) Iterate(ctx context.Context, filter store.ChunkType, fn func(addr swarm.Address, storedAt time.Time) error) error {
+	s.mu.RLock()
+	addrs := make([]swarm.Address, 0, len(s.data))
+	times := make([]time.Time, 0, len(s.data))
+	for key, chunk := range s.data {
+		if !filter.Match(store.TypeOf(chunk)) {
+			continue
+		}
+		addrs = append(addrs, chunk.Address())
+		times = append(times, s.storedAt[key])
+	}
+	s.mu.RUnlock()
+	for i, addr := range addrs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(addr, times[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the chunk at address.
+func (s *SwarmInMemoryStore) Delete(ctx context.Context, address swarm.Address) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := address.String()
+	delete(s.data, key)
+	delete(s.storedAt, key)
+	return nil
+}
+
+// Len returns the number of stored chunks.
+func (s *SwarmInMemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.data)
+}
+
 // Close closes the in-memory store (no-op for this simple implementation).
 func (s *SwarmInMemoryStore) Close() error {
 	// No resources to clean up in this simple implementation
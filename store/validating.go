@@ -0,0 +1,70 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethersphere/bee/pkg/cac"
+	"github.com/ethersphere/bee/pkg/soc"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+)
+
+// InvalidChunkError is returned when a chunk neither hashes to its address
+// nor is a correctly signed single-owner chunk.
+type InvalidChunkError struct {
+	Op      string
+	Address swarm.Address
+}
+
+func (e InvalidChunkError) Error() string {
+	return fmt.Sprintf("store: %s: invalid chunk %s", e.Op, e.Address)
+}
+
+// Is reports InvalidChunkError as storage.ErrInvalidChunk so callers can use
+// the bee sentinel with errors.Is.
+func (e InvalidChunkError) Is(target error) bool {
+	return target == storage.ErrInvalidChunk
+}
+
+// validatingStore wraps a PutGetter and rejects chunks that fail validation.
+type validatingStore struct {
+	PutGetter
+}
+
+// NewValidatingStore returns a PutGetter that verifies every chunk passing
+// through it. Content-addressed chunks must BMT hash to their address and
+// single-owner chunks must carry a valid signature.
+func NewValidatingStore(s PutGetter) PutGetter {
+	return &validatingStore{PutGetter: s}
+}
+
+// Unwrap returns the store being validated.
+func (s *validatingStore) Unwrap() PutGetter {
+	return s.PutGetter
+}
+
+// Put validates the chunk before handing it to the underlying store.
+func (s *validatingStore) Put(ctx context.Context, ch swarm.Chunk) error {
+	if !Valid(ch) {
+		return InvalidChunkError{Op: "put", Address: ch.Address()}
+	}
+	return s.PutGetter.Put(ctx, ch)
+}
+
+// Get retrieves the chunk and checks that it is valid for the requested address.
+func (s *validatingStore) Get(ctx context.Context, addr swarm.Address) (swarm.Chunk, error) {
+	ch, err := s.PutGetter.Get(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if !ch.Address().Equal(addr) || !Valid(ch) {
+		return nil, InvalidChunkError{Op: "get", Address: addr}
+	}
+	return ch, nil
+}
+
+// Valid reports whether ch is a valid content-addressed or single-owner chunk.
+func Valid(ch swarm.Chunk) bool {
+	return cac.Valid(ch) || soc.Valid(ch)
+}
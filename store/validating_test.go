@@ -0,0 +1,60 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethersphere/bee/pkg/cac"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+	"github.com/ethersphere/bee/pkg/soc"
+	"github.com/ethersphere/bee/pkg/storage"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/store"
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestValidatingStore(t *testing.T) {
+	ctx := context.Background()
+	inner := teststore.NewSwarmInMemoryStore()
+	s := store.NewValidatingStore(inner)
+
+	ch, err := cac.New([]byte("hello swarm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, ch); err != nil {
+		t.Fatalf("put valid cac: %v", err)
+	}
+	if _, err := s.Get(ctx, ch.Address()); err != nil {
+		t.Fatalf("get valid cac: %v", err)
+	}
+
+	pk, err := beecrypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sch, err := soc.New(make([]byte, swarm.HashSize), ch).Sign(beecrypto.NewDefaultSigner(pk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(ctx, sch); err != nil {
+		t.Fatalf("put valid soc: %v", err)
+	}
+
+	// A chunk whose payload does not match its address must be rejected.
+	forged := swarm.NewChunk(ch.Address(), append([]byte{}, sch.Data()...))
+	var invalid store.InvalidChunkError
+	if err := s.Put(ctx, forged); !errors.As(err, &invalid) {
+		t.Fatalf("put forged chunk: want InvalidChunkError, got %v", err)
+	}
+
+	// The same forged chunk injected behind the decorator is caught on read.
+	if err := inner.Put(ctx, forged); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, ch.Address()); !errors.Is(err, storage.ErrInvalidChunk) {
+		t.Fatalf("get forged chunk: want storage.ErrInvalidChunk, got %v", err)
+	}
+}
@@ -3,7 +3,9 @@ package swarmdriver
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -40,6 +42,12 @@ func init() {
 	logger = slog.New(handler)
 }
 
+// SetLogger replaces the logger drivers report to, which writes JSON debug
+// records to stdout by default. It must be called before drivers are created.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
 // swarmDriverFactory implements the factory.StorageDriverFactory interface.
 type swarmDriverFactory struct{}
 
@@ -51,7 +59,7 @@ func (factory *swarmDriverFactory) Create(ctx context.Context, parameters map[st
 		return nil, fmt.Errorf("Create: missing or invalid 'addr' parameter")
 	}
 	// Extract the store interface from the parameters.
-	store, ok := parameters["store"].(store.PutGetter)
+	chunkStore, ok := parameters["store"].(store.PutGetter)
 	if !ok {
 		return nil, fmt.Errorf("Create: missing or invalid 'store' parameter")
 	}
@@ -60,8 +68,104 @@ func (factory *swarmDriverFactory) Create(ctx context.Context, parameters map[st
 	if !ok {
 		return nil, fmt.Errorf("Create: missing or invalid 'encrypt' parameter")
 	}
+	// Validate chunks coming from remote backends unless explicitly
+	// disabled. Local backends return what was put and are not validated
+	// unless explicitly enabled.
+	validate := !store.IsLocal(chunkStore)
+	if v, found := parameters["validate"]; found {
+		if validate, ok = v.(bool); !ok {
+			return nil, fmt.Errorf("Create: invalid 'validate' parameter")
+		}
+	}
+	if validate {
+		chunkStore = store.NewValidatingStore(chunkStore)
+	}
+	// Retry transient store failures unless explicitly disabled.
+	resilient := true
+	if v, found := parameters["resilient"]; found {
+		if resilient, ok = v.(bool); !ok {
+			return nil, fmt.Errorf("Create: invalid 'resilient' parameter")
+		}
+	}
+	if resilient {
+		chunkStore = store.NewResilientStore(chunkStore, store.ResilientOptions{})
+	}
+	// Follow the tree published by addr instead of writing a tree of our own.
+	readOnly := false
+	if v, found := parameters["readonly"]; found {
+		if readOnly, ok = v.(bool); !ok {
+			return nil, fmt.Errorf("Create: invalid 'readonly' parameter")
+		}
+	}
+	var opts []Option
+	// Extract the optional hex encoded private key owning the feeds.
+	if v, found := parameters["privatekey"]; found {
+		signer, err := parseSigner(v)
+		if err != nil {
+			return nil, fmt.Errorf("Create: invalid 'privatekey' parameter: %w", err)
+		}
+		opts = append(opts, WithSigner(signer))
+	}
+	// Extract the optional timeout for closing writers.
+	if v, found := parameters["closetimeout"]; found {
+		timeout, err := parseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("Create: invalid 'closetimeout' parameter: %w", err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("Create: invalid 'closetimeout' parameter: %s is not positive", timeout)
+		}
+		opts = append(opts, WithCloseTimeout(timeout))
+	}
+	// Record mutations in the audit log unless explicitly disabled.
+	if v, found := parameters["auditlog"]; found {
+		auditLog, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("Create: invalid 'auditlog' parameter")
+		}
+		if !auditLog {
+			opts = append(opts, WithoutAuditLog())
+		}
+	}
+	if readOnly {
+		return NewFollower(ctx, addr, chunkStore, opts...)
+	}
 	// Create and return a new instance of swarmDriver.
-	return New(addr, store, encrypt), nil
+	return New(ctx, addr, chunkStore, encrypt, opts...)
+}
+
+// parseSigner accepts a hex encoded secp256k1 private key.
+func parseSigner(v interface{}) (beecrypto.Signer, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+	return SignerFromHex(s)
+}
+
+// SignerFromHex returns a signer for the hex encoded secp256k1 private key s,
+// as accepted by the 'privatekey' parameter.
+func SignerFromHex(s string) (beecrypto.Signer, error) {
+	buf, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		return nil, err
+	}
+	pk, err := beecrypto.DecodeSecp256k1PrivateKey(buf)
+	if err != nil {
+		return nil, err
+	}
+	return beecrypto.NewDefaultSigner(pk), nil
+}
+
+// parseDuration accepts a time.Duration or a string understood by time.ParseDuration.
+func parseDuration(v interface{}) (time.Duration, error) {
+	switch t := v.(type) {
+	case time.Duration:
+		return t, nil
+	case string:
+		return time.ParseDuration(t)
+	}
+	return 0, fmt.Errorf("unsupported type %T", v)
 }
 
 // Publisher is an interface for publishing data references.
@@ -78,13 +182,32 @@ type Lookuper interface {
 
 // swarmDriver is the main struct implementing the storagedriver.StorageDriver interface.
 type swarmDriver struct {
-	mutex     sync.RWMutex    // Mutex to handle concurrent access.
-	synced    bool            // Flag to indicate if the driver is synced.
-	store     store.PutGetter // Interface for storing and retrieving data.
-	encrypt   bool            // Flag to indicate if encryption is enabled.
-	publisher Publisher       // Interface for publishing data references.
-	lookuper  Lookuper        // Interface for looking up data references.
-	splitter  file.Splitter   // Interface for splitting files into chunks.
+	mutex        sync.RWMutex        // Mutex to handle concurrent access.
+	synced       bool                // Flag to indicate if the driver is synced.
+	store        store.PutGetter     // Interface for storing and retrieving data.
+	owner        common.Address      // Owner of the feeds the driver reads.
+	encrypt      bool                // Flag to indicate if encryption is enabled.
+	publisher    Publisher           // Interface for publishing data references.
+	lookuper     Lookuper            // Interface for looking up data references.
+	splitter     file.Splitter       // Interface for splitting files into chunks.
+	closeTimeout time.Duration       // Upper bound for storing data in swarmFile.Close.
+	lifecycle    sync.Mutex          // Guards closed, writers and additions to inflight.
+	closed       bool                // Flag to indicate if the driver has been closed.
+	inflight     sync.WaitGroup      // Operations that started before Close.
+	writers      writerSet           // Writers that were neither closed nor cancelled.
+	readOnly     bool                // Flag to indicate if mutations are refused.
+	base         *swarmDriver        // Driver a view was derived from, nil otherwise.
+	auditLog     bool                // Flag to indicate if mutations are recorded in the audit log.
+	auditErr     error               // First failure to append to the audit log, reported by VerifyAuditLog.
+	watch        *watchHub           // In-process watchers, shared with views.
+	signer       beecrypto.Signer    // Signer set with WithSigner, nil for a generated key.
+	pinner       store.Pinner        // Pinner set with WithPinner, nil to use the store's.
+	pins         *pinTracker         // Pinned data references, nil without a pinner.
+	quotas       map[string]Quota    // Quotas by prefix, nil for read-only drivers.
+	uploads      map[string]struct{} // Paths with closed but uncommitted data, nil for read-only drivers.
+	stats        *statsCache         // Cached usage statistics, nil for views.
+	digestAlgs   []string            // Digests recorded besides SHA256, set with WithDigests.
+	verifyReads  bool                // Flag to indicate if reads are checked against the recorded digests.
 }
 
 // metaData represents the metadata for a file or directory.
@@ -94,6 +217,19 @@ type metaData struct {
 	ModTime  int64    // The modification time of the file or directory.
 	Size     int      // The size of the file.
 	Children []string // List of children paths if the path is a directory.
+	// Hex digests of the content of a file by algorithm, recorded on write.
+	Digests map[string]string `json:",omitempty"`
+	// References of the children of a directory by name. A change anywhere
+	// below a directory republishes it, so equal directory references mean
+	// equal subtrees. Directories written before it was recorded lack it.
+	Refs map[string]childRef `json:",omitempty"`
+}
+
+// childRef is what a directory records of one of its children: the
+// references its feeds resolved to when the directory was published.
+type childRef struct {
+	Meta swarm.Address `json:"meta"`
+	Data swarm.Address `json:"data,omitempty"` // Zero for directories and empty files.
 }
 
 var _ storagedriver.StorageDriver = &swarmDriver{}
@@ -107,42 +243,122 @@ func isZeroAddress(ref swarm.Address) bool {
 	return swarm.NewAddress(zeroAddr).Equal(ref)
 }
 
-// New constructs a new Driver.
-// New constructs a new swarmDriver instance.
-func New(addr common.Address, store store.PutGetter, encrypt bool) *swarmDriver {
-	logger.Debug("Creating New Swarm Driver")
-	// Generate a new Secp256k1 private key.
-	pk, err := beecrypto.GenerateSecp256k1Key()
+// split stores buf in the chunk store and returns its root reference.
+func (d *swarmDriver) split(ctx context.Context, buf []byte) (swarm.Address, error) {
+	ref, err := d.splitter.Split(ctx, io.NopCloser(bytes.NewReader(buf)), int64(len(buf)), d.encrypt)
 	if err != nil {
-		panic(err)
+		return swarm.ZeroAddress, err
+	}
+	if isZeroAddress(ref) {
+		return swarm.ZeroAddress, errZeroReference
+	}
+	return ref, nil
+}
+
+// New constructs a new swarmDriver instance. The root directory is
+// initialized with ctx, so a cancelled or expired context aborts construction.
+// Feeds are owned by the address of the signer set with WithSigner, or of a
+// freshly generated key otherwise; addr is not used for them. A driver
+// constructed with the signer of an existing tree continues that tree; with
+// a pinner, New walks the whole tree to count the paths linking every
+// reference.
+func New(ctx context.Context, addr common.Address, store store.PutGetter, encrypt bool, opts ...Option) (*swarmDriver, error) {
+	logger.Debug("Creating New Swarm Driver")
+	// Create a new instance of swarmDriver with the provided parameters.
+	d := &swarmDriver{
+		store:        store,
+		encrypt:      encrypt,
+		splitter:     splitter.NewSimpleSplitter(store),
+		closeTimeout: defaultCloseTimeout,
+		auditLog:     true,
+		watch:        newWatchHub(),
+		stats:        newStatsCache(true),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	for _, alg := range d.digestAlgs {
+		if _, ok := digestHashes[alg]; !ok {
+			return nil, fmt.Errorf("New: unsupported digest algorithm %q", alg)
+		}
+	}
+	if d.closeTimeout <= 0 {
+		return nil, fmt.Errorf("New: close timeout %s is not positive", d.closeTimeout)
+	}
+	signer := d.signer
+	if signer == nil {
+		// Generate a new Secp256k1 private key.
+		pk, err := beecrypto.GenerateSecp256k1Key()
+		if err != nil {
+			return nil, fmt.Errorf("New: failed to generate key: %w", err)
+		}
+		// Create a new signer using the generated private key.
+		signer = beecrypto.NewDefaultSigner(pk)
 	}
-	// Create a new signer using the generated private key.
-	signer := beecrypto.NewDefaultSigner(pk)
 	// Get the Ethereum address associated with the signer.
 	ethAddress, err := signer.EthereumAddress()
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("New: failed to derive signer address: %w", err)
 	}
+	d.owner = ethAddress
 	// Initialize the lookuper with the store and Ethereum address.
-	lk := lookuper.New(store, ethAddress)
-	// Initialize the publisher with the store, signer, and the latest lookuper.
-	pb := publisher.New(store, signer, lookuper.Latest(store, addr))
-	// Initialize the splitter for splitting files into chunks.
-	splitter := splitter.NewSimpleSplitter(store)
-	// Create a new instance of swarmDriver with the provided parameters.
-	d := &swarmDriver{
-		store:     store,
-		encrypt:   encrypt,
-		lookuper:  lk,
-		publisher: pb,
-		splitter:  splitter,
-	}
+	d.lookuper = lookuper.New(store, ethAddress)
+	// Initialize the publisher with the store, signer, and the latest
+	// lookuper, so that existing feeds of the signer are continued.
+	d.publisher = publisher.New(store, signer, lookuper.Latest(store, ethAddress))
 	// Add the root path to the driver.
-	if err := d.addPathToRoot(context.Background(), ""); err != nil {
-		logger.Error("New: Failed to create root path:")
+	if err := d.addPathToRoot(ctx, ""); err != nil {
+		return nil, fmt.Errorf("New: failed to create root path: %w", err)
+	}
+	// Count the references of an existing tree, so that deleting a path
+	// later releases them.
+	d.pins = newPinTracker(store, d.pinner)
+	if err := d.pins.sync(ctx, d); err != nil {
+		return nil, fmt.Errorf("New: failed to pin tree: %w", err)
+	}
+	if d.quotas, err = d.loadQuotas(ctx); err != nil {
+		return nil, fmt.Errorf("New: %w", err)
+	}
+	if d.uploads, err = d.loadUploads(ctx); err != nil {
+		return nil, fmt.Errorf("New: %w", err)
 	}
 	logger.Debug("Swarm driver successfully created!")
-	return d
+	return d, nil
+}
+
+// NewFollower constructs a read-only swarmDriver that follows the tree
+// published by owner. It holds no signer: reads resolve owner's feeds in
+// store, mutating methods fail with ErrReadOnly. The tree must have been
+// created by owner, ctx is used to check that its root exists.
+func NewFollower(ctx context.Context, owner common.Address, store store.PutGetter, opts ...Option) (*swarmDriver, error) {
+	logger.Debug("Creating New Swarm Follower", slog.String("owner", owner.Hex()))
+	d := &swarmDriver{
+		store:        store,
+		owner:        owner,
+		lookuper:     lookuper.New(store, owner),
+		splitter:     splitter.NewSimpleSplitter(store),
+		closeTimeout: defaultCloseTimeout,
+		readOnly:     true,
+		watch:        newWatchHub(),
+		stats:        newStatsCache(false),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.closeTimeout <= 0 {
+		return nil, fmt.Errorf("NewFollower: close timeout %s is not positive", d.closeTimeout)
+	}
+	if _, err := d.getMetadata(ctx, "/"); err != nil {
+		return nil, fmt.Errorf("NewFollower: failed to read root of %s: %w", owner.Hex(), err)
+	}
+	logger.Debug("Swarm follower successfully created!")
+	return d, nil
+}
+
+// Owner returns the address owning the feeds the driver reads. Pass it to
+// NewFollower to follow the driver's tree.
+func (d *swarmDriver) Owner() common.Address {
+	return d.owner
 }
 
 // Implement the storagedriver.StorageDriver interface.
@@ -189,6 +405,10 @@ func (d *swarmDriver) addPathToRoot(ctx context.Context, path string) error {
 	rootPath := "/"
 	// Retrieve root metadata
 	rootMeta, err := d.getMetadata(ctx, rootPath)
+	if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+		// Initializing the root here would drop an existing tree.
+		return fmt.Errorf("addPathToRoot: failed to get root metadata: %w", err)
+	}
 	if err != nil {
 		// If root metadata does not exist, initialize it
 		rootMeta = metaData{
@@ -216,17 +436,17 @@ func (d *swarmDriver) addPathToRoot(ctx context.Context, path string) error {
 	// Marshal the updated root metadata to JSON
 	metaBuf, err := json.Marshal(rootMeta)
 	if err != nil {
-		return fmt.Errorf("addPathToRoot: failed to marshal metadata: %v", err)
+		return fmt.Errorf("addPathToRoot: failed to marshal metadata: %w", err)
 	}
 	// Split the metadata and get a reference
-	metaRef, err := d.splitter.Split(ctx, io.NopCloser(bytes.NewReader(metaBuf)), int64(len(metaBuf)), d.encrypt)
-	if err != nil || isZeroAddress(metaRef) {
-		return fmt.Errorf("addPathToRoot: failed to split metadata: %v", err)
+	metaRef, err := d.split(ctx, metaBuf)
+	if err != nil {
+		return fmt.Errorf("addPathToRoot: failed to split metadata: %w", err)
 	}
 	// Publish the metadata
 	err = d.publisher.Put(ctx, filepath.Join(rootPath, "mtdt"), time.Now().Unix(), metaRef)
 	if err != nil {
-		return fmt.Errorf("addPathToRoot: failed to publish metadata: %v", err)
+		return fmt.Errorf("addPathToRoot: failed to publish metadata: %w", err)
 	}
 	logger.Debug("addPathToRoot: Success!", slog.String("path", path))
 	return nil
@@ -255,56 +475,79 @@ func (d *swarmDriver) getMetadata(ctx context.Context, path string) (metaData, e
 	// Lookup the metadata reference for the given path.
 	metaRef, err := d.lookuper.Get(ctx, filepath.Join(path, "mtdt"), time.Now().Unix())
 	if err != nil {
-		return metaData{}, fmt.Errorf("getMetadata: failed to get metadata for path %s %v", path, err)
+		return metaData{}, fmt.Errorf("getMetadata: failed to get metadata for path %s %w", path, err)
+	}
+	// A zero reference marks deleted metadata.
+	if isZeroAddress(metaRef) {
+		return metaData{}, fmt.Errorf("getMetadata: metadata for path %s deleted: %w", path, lookuper.ErrNotFound)
 	}
 	// Create a joiner to read the metadata.
 	metaJoiner, _, err := joiner.New(ctx, d.store, metaRef)
 	if err != nil {
-		return metaData{}, fmt.Errorf("getMetadata: failed to create reader for metadata: %v", err)
+		return metaData{}, fmt.Errorf("getMetadata: failed to create reader for metadata: %w", err)
 	}
 	// Read and unmarshal the metadata.
 	meta, err := fromMetadata(metaJoiner)
 	if err != nil {
-		return metaData{}, fmt.Errorf("getMetadata: failed to read metadata: %v", err)
+		return metaData{}, fmt.Errorf("getMetadata: failed to read metadata: %w", err)
 	}
 	return meta, nil
 }
 
+// putMetadata publishes meta for path and links it into every parent
+// directory up to the root.
 func (d *swarmDriver) putMetadata(ctx context.Context, path string, meta metaData) error {
 	logger.Debug("putMetadata Hit", slog.String("path", path))
 	// Marshal the metadata to JSON
 	metaBuf, err := json.Marshal(meta)
 	if err != nil {
-		return fmt.Errorf("putMetadata: failed to marshal metadata: %v", err)
+		return fmt.Errorf("putMetadata: failed to marshal metadata: %w", err)
 	}
 	// Split the metadata and get a reference
-	metaRef, err := d.splitter.Split(ctx, io.NopCloser(bytes.NewReader(metaBuf)), int64(len(metaBuf)), d.encrypt)
-	if err != nil || isZeroAddress(metaRef) {
-		return fmt.Errorf("putMetadata: failed to split metadata: %v", err)
+	metaRef, err := d.split(ctx, metaBuf)
+	if err != nil {
+		return fmt.Errorf("putMetadata: failed to split metadata: %w", err)
 	}
 	// Publish the metadata
 	err = d.publisher.Put(ctx, filepath.Join(path, "mtdt"), time.Now().Unix(), metaRef)
 	if err != nil {
-		return fmt.Errorf("putMetadata: failed to publish metadata: %v", err)
+		return fmt.Errorf("putMetadata: failed to publish metadata: %w", err)
 	}
-	// If the path is the root, no need to update parent directories
-	if path == "/" {
-		return nil
+	ref := childRef{Meta: metaRef}
+	if !meta.IsDir {
+		dataRef, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
+		if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+			return fmt.Errorf("putMetadata: failed to lookup data: %w", err)
+		}
+		if err == nil && !isZeroAddress(dataRef) {
+			ref.Data = dataRef
+		}
 	}
-	// Update metadata for each parent directory up to the root
-	for currentPath := filepath.ToSlash(filepath.Dir(path)); ; currentPath = filepath.ToSlash(filepath.Dir(currentPath)) {
-		// Retrieve parent metadata
-		parentMeta, err := d.getMetadata(ctx, currentPath)
+	if err := d.linkParents(ctx, path, ref); err != nil {
+		return fmt.Errorf("putMetadata: %w", err)
+	}
+	logger.Debug("putMetadata: Success!", slog.String("path", path))
+	return nil
+}
+
+// linkParents records ref as the reference of path in its parent directory
+// and republishes every directory up to the root, creating the missing ones,
+// so that each records the new reference of the child below it. Every write
+// therefore publishes one metadata update per level of the path.
+func (d *swarmDriver) linkParents(ctx context.Context, path string, ref childRef) error {
+	for path != "/" {
+		parentPath := filepath.ToSlash(filepath.Dir(path))
+		parentMeta, err := d.getMetadata(ctx, parentPath)
 		if err != nil {
-			logger.Warn("putMetadata: Metadata not found. Creating new", slog.String("path", currentPath))
+			logger.Warn("linkParents: Metadata not found. Creating new", slog.String("path", parentPath))
 			parentMeta = metaData{
 				IsDir:    true,
-				Path:     currentPath,
+				Path:     parentPath,
 				ModTime:  time.Now().Unix(),
 				Children: []string{},
 			}
 		}
-		// Check if the current path is already a child of the parent
+		// Add the path to the parent's children if not already present
 		childPath := filepath.Base(path)
 		found := false
 		for _, child := range parentMeta.Children {
@@ -313,35 +556,30 @@ func (d *swarmDriver) putMetadata(ctx context.Context, path string, meta metaDat
 				break
 			}
 		}
-		// Add the current path to the parent's children if not already present
 		if !found {
 			parentMeta.Children = append(parentMeta.Children, childPath)
 			parentMeta.ModTime = time.Now().Unix()
-
-			// Marshal the updated parent metadata to JSON
-			parentMetaBuf, err := json.Marshal(parentMeta)
-			if err != nil {
-				return fmt.Errorf("putMetadata: failed to marshal parent metadata: %v", err)
-			}
-			// Split the parent metadata and get a reference
-			parentMetaRef, err := d.splitter.Split(ctx, io.NopCloser(bytes.NewReader(parentMetaBuf)), int64(len(parentMetaBuf)), d.encrypt)
-			if err != nil || isZeroAddress(parentMetaRef) {
-				return fmt.Errorf("putMetadata: failed to split parent metadata: %v", err)
-			}
-			// Publish the parent metadata
-			err = d.publisher.Put(ctx, filepath.Join(currentPath, "mtdt"), time.Now().Unix(), parentMetaRef)
-			if err != nil {
-				return fmt.Errorf("putMetadata: failed to publish parent metadata: %v", err)
-			}
 		}
-		// Break the loop if we have reached the root
-		if currentPath == "/" {
-			break
+		if parentMeta.Refs == nil {
+			parentMeta.Refs = make(map[string]childRef)
+		}
+		parentMeta.Refs[childPath] = ref
+		parentMetaBuf, err := json.Marshal(parentMeta)
+		if err != nil {
+			return fmt.Errorf("linkParents: failed to marshal parent metadata: %w", err)
+		}
+		parentMetaRef, err := d.split(ctx, parentMetaBuf)
+		if err != nil {
+			return fmt.Errorf("linkParents: failed to split parent metadata: %w", err)
+		}
+		err = d.publisher.Put(ctx, filepath.Join(parentPath, "mtdt"), time.Now().Unix(), parentMetaRef)
+		if err != nil {
+			return fmt.Errorf("linkParents: failed to publish parent metadata: %w", err)
 		}
 		// Move up to the parent directory
-		path = currentPath
+		path = parentPath
+		ref = childRef{Meta: parentMetaRef}
 	}
-	logger.Debug("putMetadata: Success!", slog.String("path", path))
 	return nil
 }
 
@@ -350,12 +588,16 @@ func (d *swarmDriver) getData(ctx context.Context, path string) ([]byte, error)
 	// Lookup the data reference for the given path.
 	dataRef, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
 	if err != nil {
-		return nil, fmt.Errorf("getData: failed to lookup data: %v", err)
+		return nil, fmt.Errorf("getData: failed to lookup data: %w", err)
+	}
+	// A zero reference is published for empty content.
+	if isZeroAddress(dataRef) {
+		return []byte{}, nil
 	}
 	// Create a joiner to read the data.
 	dataJoiner, _, err := joiner.New(ctx, d.store, dataRef)
 	if err != nil {
-		return nil, fmt.Errorf("getData: failed to create joiner for data: %v", err)
+		return nil, fmt.Errorf("getData: failed to create joiner for data: %w", err)
 	}
 	// Read all data from the joiner into a byte slice.
 	data, err := io.ReadAll(dataJoiner)
@@ -365,8 +607,9 @@ func (d *swarmDriver) getData(ctx context.Context, path string) ([]byte, error)
 	return data, nil
 }
 
-// putData stores the provided data at the specified path.
-func (d *swarmDriver) putData(ctx context.Context, path string, data []byte) error {
+// putData stores the provided data at the specified path and returns the
+// published data reference.
+func (d *swarmDriver) putData(ctx context.Context, path string, data []byte) (swarm.Address, error) {
 	logger.Debug("putData Hit", slog.String("path", path))
 	// Check if the data is empty.
 	if len(data) == 0 {
@@ -375,21 +618,33 @@ func (d *swarmDriver) putData(ctx context.Context, path string, data []byte) err
 		// Publish an empty data reference.
 		err := d.publisher.Put(ctx, filepath.Join(path, "data"), time.Now().Unix(), emptyRef)
 		if err != nil {
-			return fmt.Errorf("putData: failed to publish empty data reference: %v", err)
+			return swarm.ZeroAddress, fmt.Errorf("putData: failed to publish empty data reference: %w", err)
 		}
-		return nil
+		if err := d.pins.set(ctx, path, emptyRef); err != nil {
+			return swarm.ZeroAddress, fmt.Errorf("putData: %w", err)
+		}
+		return emptyRef, nil
 	}
 	// Split the data into chunks and get a reference.
-	dataRef, err := d.splitter.Split(ctx, io.NopCloser(bytes.NewReader(data)), int64(len(data)), d.encrypt)
-	if err != nil || isZeroAddress(dataRef) {
-		return fmt.Errorf("putData: failed to split data: %v", err)
+	dataRef, err := d.split(ctx, data)
+	if err != nil {
+		return swarm.ZeroAddress, fmt.Errorf("putData: failed to split data: %w", err)
+	}
+	// Pin the data before the feed links it, and release the pin again if
+	// publishing fails.
+	if err := d.pins.hold(ctx, dataRef); err != nil {
+		return swarm.ZeroAddress, fmt.Errorf("putData: %w", err)
 	}
+	defer d.pins.unhold(ctx, dataRef)
 	// Publish the data reference.
 	err = d.publisher.Put(ctx, filepath.Join(path, "data"), time.Now().Unix(), dataRef)
 	if err != nil {
-		return fmt.Errorf("putData: failed to publish data reference: %v", err)
+		return swarm.ZeroAddress, fmt.Errorf("putData: failed to publish data reference: %w", err)
 	}
-	return nil
+	if err := d.pins.set(ctx, path, dataRef); err != nil {
+		return swarm.ZeroAddress, fmt.Errorf("putData: %w", err)
+	}
+	return dataRef, nil
 }
 
 // deleteData nullifies the data reference for the given path by publishing a ZeroAddress.
@@ -399,7 +654,7 @@ func (d *swarmDriver) deleteData(ctx context.Context, path string) error {
 	// Publish a ZeroAddress to nullify the data reference.
 	err := d.publisher.Put(ctx, dataRefPath, time.Now().Unix(), swarm.ZeroAddress)
 	if err != nil {
-		return fmt.Errorf("deleteData: failed to nullify data reference for path %s: %v", path, err)
+		return fmt.Errorf("deleteData: failed to nullify data reference for path %s: %w", path, err)
 	}
 	return nil
 }
@@ -411,7 +666,7 @@ func (d *swarmDriver) deleteMetadata(ctx context.Context, path string) error {
 	// Publish a ZeroAddress to nullify the metadata reference.
 	err := d.publisher.Put(ctx, metadataRefPath, time.Now().Unix(), swarm.ZeroAddress)
 	if err != nil {
-		return fmt.Errorf("deleteMetadata: failed to nullify metadata for path %s: %v", path, err)
+		return fmt.Errorf("deleteMetadata: failed to nullify metadata for path %s: %w", path, err)
 	}
 	return nil
 }
@@ -438,7 +693,7 @@ func (d *swarmDriver) childExists(ctx context.Context, path string) error {
 			}
 		}
 		if !found {
-			return fmt.Errorf("childExists: child %s not found in parent %s", childPath, parentPath)
+			return fmt.Errorf("childExists: child %s not found in parent %s: %w", childPath, parentPath, errChildNotFound)
 		}
 		// If we have reached the root, break the loop
 		if parentPath == "/" {
@@ -453,6 +708,10 @@ func (d *swarmDriver) childExists(ctx context.Context, path string) error {
 
 // GetContent retrieves the content stored at "path" as a []byte.
 func (d *swarmDriver) GetContent(ctx context.Context, path string) ([]byte, error) {
+	if err := d.acquire(); err != nil {
+		return nil, d.pathError(path, err)
+	}
+	defer d.release()
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 	logger.Debug("GetContent Hit", slog.String("path", path))
@@ -462,12 +721,12 @@ func (d *swarmDriver) GetContent(ctx context.Context, path string) ([]byte, erro
 	}
 	if err := d.childExists(ctx, path); err != nil {
 		logger.Error("GetContent: Child not found", slog.String("error", err.Error()))
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return nil, d.pathError(path, err)
 	}
 	// Fetch metadata using the helper function
 	mtdt, err := d.getMetadata(ctx, path)
 	if err != nil {
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return nil, d.pathError(path, err)
 	}
 	// Check if data is a directory
 	if mtdt.IsDir {
@@ -476,13 +735,27 @@ func (d *swarmDriver) GetContent(ctx context.Context, path string) ([]byte, erro
 	// Fetch data using the helper function
 	data, err := d.getData(ctx, path)
 	if err != nil {
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return nil, d.pathError(path, err)
+	}
+	if dg := recordedDigester(mtdt.Digests); d.verifyReads && dg != nil {
+		dg.Write(data)
+		if err := dg.verify(path, mtdt.Digests); err != nil {
+			logger.Error("GetContent: Digest mismatch", slog.String("path", path), slog.String("error", err.Error()))
+			return nil, d.pathError(path, err)
+		}
 	}
 	logger.Debug("GetContent: Success!", slog.String("path", path))
 	return data, nil
 }
 
 func (d *swarmDriver) PutContent(ctx context.Context, path string, content []byte) error {
+	if err := d.acquire(); err != nil {
+		return d.pathError(path, err)
+	}
+	defer d.release()
+	if d.readOnly {
+		return d.pathError(path, ErrReadOnly)
+	}
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 	logger.Debug("PutContent Hit", slog.String("path", path))
@@ -490,10 +763,20 @@ func (d *swarmDriver) PutContent(ctx context.Context, path string, content []byt
 		logger.Error("GetContent: Invalid path", slog.String("error", err.Error()))
 		return storagedriver.InvalidPathError{DriverName: d.Name()}
 	}
+	oldSize, err := d.checkQuota(ctx, path, int64(len(content)))
+	if err != nil {
+		return d.pathError(path, err)
+	}
+	existed := d.watchedExists(ctx, path)
+	oldRef, err := d.auditDataRef(ctx, path)
+	if err != nil {
+		return d.pathError(path, err)
+	}
 	// Split the content to get a data reference
-	if err := d.putData(ctx, path, content); err != nil {
+	newRef, err := d.putData(ctx, path, content)
+	if err != nil {
 		logger.Error("PutContent: putData Failed!", slog.String("path", path))
-		return storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return d.pathError(path, err)
 	}
 	// Create and store metadata for the new content
 	mtdt := metaData{
@@ -501,11 +784,21 @@ func (d *swarmDriver) PutContent(ctx context.Context, path string, content []byt
 		Path:    path,
 		ModTime: time.Now().Unix(),
 		Size:    len(content),
+		Digests: d.digests(content),
 	}
 	if err := d.putMetadata(ctx, path, mtdt); err != nil {
 		logger.Error("PutContent: putMetaData Failed!", slog.String("path", path))
-		return storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return d.pathError(path, err)
+	}
+	if err := d.chargeQuota(ctx, map[string]int64{path: oldSize}, map[string]int64{path: int64(len(content))}); err != nil {
+		return d.pathError(path, err)
+	}
+	d.stats.set(path, int64(len(content)), newRef)
+	if err := d.removeUploads(ctx, path); err != nil {
+		return d.pathError(path, err)
 	}
+	d.audit(ctx, AuditPutContent, path, "", oldRef, newRef)
+	d.notify(writeEvent(existed), path, "", newRef)
 	logger.Debug("PutContent: Success!", slog.String("path", path))
 	return nil
 }
@@ -513,6 +806,10 @@ func (d *swarmDriver) PutContent(ctx context.Context, path string, content []byt
 // Reader retrieves an io.ReadCloser for the content stored at "path" with a
 // given byte offset.
 func (d *swarmDriver) Reader(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	if err := d.acquire(); err != nil {
+		return nil, d.pathError(path, err)
+	}
+	defer d.release()
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 	logger.Debug("Reader Hit", slog.String("path", path))
@@ -522,71 +819,129 @@ func (d *swarmDriver) Reader(ctx context.Context, path string, offset int64) (io
 	}
 	if err := d.childExists(ctx, path); err != nil {
 		logger.Error("Reader: Child not found", slog.String("error", err.Error()))
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return nil, d.pathError(path, err)
 	}
 	// Lookup data reference for the given path
 	dataRef, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
-	if err != nil && !dataRef.Equal(swarm.ZeroAddress) {
+	if err != nil {
 		logger.Error("Reader: Failed to lookup data reference", slog.String("path", path), slog.String("error", err.Error()), "dataref", dataRef)
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
-	} else if dataRef.Equal(swarm.ZeroAddress) {
+		return nil, d.pathError(path, err)
+	}
+	var want map[string]string
+	if d.verifyReads {
+		mtdt, err := d.getMetadata(ctx, path)
+		if err != nil {
+			return nil, d.pathError(path, err)
+		}
+		want = mtdt.Digests
+	}
+	var dataJoiner io.ReadSeeker
+	if dataRef.Equal(swarm.ZeroAddress) {
 		logger.Warn("Reader: Data reference is zero", slog.String("path", path), "dataref", dataRef)
-		return io.NopCloser(bytes.NewReader([]byte{})), nil
+		if want == nil {
+			return d.openReader(bytes.NewReader([]byte{})), nil
+		}
+		dataJoiner = bytes.NewReader([]byte{})
+	} else {
+		// Create a joiner to read the data
+		dataJoiner, _, err = joiner.New(ctx, d.store, dataRef)
+		if err != nil {
+			logger.Error("Reader: Failed to create joiner", slog.String("path", path))
+			return nil, d.pathError(path, err)
+		}
 	}
-	// Create a joiner to read the data
-	dataJoiner, _, err := joiner.New(ctx, d.store, dataRef)
-	if err != nil {
-		logger.Error("Reader: Failed to create joiner", slog.String("path", path))
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+	if dg := recordedDigester(want); dg != nil {
+		// The digest covers the whole file, so the content before offset is
+		// read and hashed instead of skipped.
+		vr := &verifyingReader{r: dataJoiner, path: path, dg: dg, want: want}
+		if _, err := io.CopyN(io.Discard, vr, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, d.pathError(path, err)
+		}
+		logger.Debug("Reader: Success", slog.String("path", path), slog.Bool("verified", true))
+		return d.openReader(vr), nil
 	}
 	// Seek to the specified offset
-	if _, err := dataJoiner.Seek(offset, io.SeekStart); err != nil {
+	if _, err := dataJoiner.Seek(offset, io.SeekStart); errors.Is(err, io.EOF) {
+		// Reading past the end yields no content.
+		return d.openReader(bytes.NewReader([]byte{})), nil
+	} else if err != nil {
 		logger.Error("Reader: Failed to seek to offset", slog.String("path", path), slog.Int64("offset", offset), slog.String("error", err.Error()))
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return nil, d.pathError(path, err)
 	}
 	logger.Debug("Reader: Success", slog.String("path", path))
-	return io.NopCloser(dataJoiner), nil
+	return d.openReader(dataJoiner), nil
 }
 
-// Stat returns info about the provided path.
+// Stat returns info about the provided path as a FileInfo. Only the metadata
+// feed is resolved, so DataRef and DataIndex are zero; callers that need the
+// data reference use SwarmStat.
 func (d *swarmDriver) Stat(ctx context.Context, path string) (storagedriver.FileInfo, error) {
+	if err := d.acquire(); err != nil {
+		return nil, d.pathError(path, err)
+	}
+	defer d.release()
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 	logger.Debug("Stat Hit", slog.String("path", path))
-	// Fetch metadata using the helper function
-	mtdt, err := d.getMetadata(ctx, path)
+	fi, err := d.fileInfo(ctx, path, false)
 	if err != nil {
 		logger.Info("Stat: Failed to lookup Metadata path", slog.String("path", path))
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return nil, d.pathError(path, err)
 	}
-	// Construct FileInfoFields from metadata
-	fi := storagedriver.FileInfoFields{
-		Path:    path,
-		IsDir:   mtdt.IsDir,
-		ModTime: time.Unix(mtdt.ModTime, 0),
+	logger.Debug("Stat: Success!", slog.String("path", path), slog.Any("fi", fi.FileInfoFields))
+	return fi, nil
+}
+
+// SetModTime records t as the modification time of path. It is used to
+// preserve modification times when content is copied into the driver. Like
+// any other metadata change it publishes a metadata feed update, which is
+// recorded in the audit log and reported to watchers as an update.
+func (d *swarmDriver) SetModTime(ctx context.Context, path string, t time.Time) error {
+	if err := d.acquire(); err != nil {
+		return d.pathError(path, err)
+	}
+	defer d.release()
+	if d.readOnly {
+		return d.pathError(path, ErrReadOnly)
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	logger.Debug("SetModTime Hit", slog.String("path", path))
+	mtdt, err := d.getMetadata(ctx, path)
+	if err != nil {
+		return d.pathError(path, err)
 	}
-	// Set the size if it's not a directory
-	if !fi.IsDir {
-		fi.Size = int64(mtdt.Size)
+	dataRef, err := d.auditDataRef(ctx, path)
+	if err != nil {
+		return d.pathError(path, err)
 	}
-	logger.Debug("Stat: Success!", slog.String("path", path), slog.Any("fi", fi))
-	return storagedriver.FileInfoInternal{FileInfoFields: fi}, nil
+	mtdt.ModTime = t.Unix()
+	if err := d.putMetadata(ctx, path, mtdt); err != nil {
+		return d.pathError(path, err)
+	}
+	d.audit(ctx, AuditSetModTime, path, "", dataRef, dataRef)
+	d.notify(EventUpdate, path, "", dataRef)
+	return nil
 }
 
 // List returns a list of the objects that are direct descendants of the given path.
 func (d *swarmDriver) List(ctx context.Context, path string) ([]string, error) {
+	if err := d.acquire(); err != nil {
+		return nil, d.pathError(path, err)
+	}
+	defer d.release()
 	d.mutex.RLock()
 	defer d.mutex.RUnlock()
 	logger.Debug("List Hit", slog.String("path", path))
 	if err := d.childExists(ctx, path); err != nil {
 		logger.Error("List: Child not found", slog.String("error", err.Error()))
-		return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+		return nil, d.pathError(path, err)
 	}
 	// Fetch metadata using the helper function
 	mtdt, err := d.getMetadata(ctx, path)
 	if err != nil {
 		logger.Error("List: Failed to lookup Metadata path", slog.String("path", path))
-		return nil, storagedriver.PathNotFoundError{Path: filepath.ToSlash(path), DriverName: d.Name()}
+		return nil, d.pathError(filepath.ToSlash(path), err)
 	}
 	// Ensure it's a directory
 	if !mtdt.IsDir {
@@ -606,9 +961,28 @@ func (d *swarmDriver) List(ctx context.Context, path string) ([]string, error) {
 }
 
 func (d *swarmDriver) Delete(ctx context.Context, path string) error {
+	if err := d.acquire(); err != nil {
+		return d.pathError(path, err)
+	}
+	defer d.release()
+	if d.readOnly {
+		return d.pathError(path, ErrReadOnly)
+	}
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 	logger.Debug("Delete Hit", slog.String("path", path))
+	if _, err := d.getMetadata(ctx, path); err != nil {
+		logger.Error("Delete: Failed to get Metadata", slog.String("path", path))
+		return d.pathError(path, err)
+	}
+	oldRef, err := d.auditDataRef(ctx, path)
+	if err != nil {
+		return d.pathError(path, err)
+	}
+	removed, err := d.quotaFiles(ctx, path)
+	if err != nil {
+		return d.pathError(path, err)
+	}
 	if path != "/" {
 		// Remove the path from the parent's children
 		parentPath := filepath.ToSlash(filepath.Dir(path))
@@ -616,131 +990,165 @@ func (d *swarmDriver) Delete(ctx context.Context, path string) error {
 		parentMeta, err := d.getMetadata(ctx, parentPath)
 		if err != nil {
 			logger.Error("Delete: Failed to get parent Metadata", slog.String("childPath", parentPath))
-			return storagedriver.PathNotFoundError{DriverName: d.Name(), Path: parentPath}
+			return d.pathError(parentPath, err)
 		}
 		parentMeta.Children = removeFromSlice(parentMeta.Children, childPath)
-		parentMetaBuf, err := json.Marshal(parentMeta)
-		if err != nil {
-			return storagedriver.PathNotFoundError{DriverName: d.Name(), Path: parentPath}
-		}
-		parentMetaRef, err := d.splitter.Split(ctx, io.NopCloser(bytes.NewReader(parentMetaBuf)), int64(len(parentMetaBuf)), d.encrypt)
-		if err != nil || isZeroAddress(parentMetaRef) {
-			return storagedriver.PathNotFoundError{DriverName: d.Name(), Path: parentPath}
-		}
-		err = d.publisher.Put(ctx, filepath.Join(parentPath, "mtdt"), time.Now().Unix(), parentMetaRef)
-		if err != nil {
-			return storagedriver.PathNotFoundError{DriverName: d.Name(), Path: parentPath}
+		delete(parentMeta.Refs, childPath)
+		if err := d.putMetadata(ctx, parentPath, parentMeta); err != nil {
+			return d.pathError(parentPath, err)
 		}
 	}
 	// Delete data and metadata
 	if err := d.deleteData(ctx, path); err != nil {
-		return storagedriver.PathNotFoundError{DriverName: d.Name(), Path: path}
+		return d.pathError(path, err)
 	}
 	if err := d.deleteMetadata(ctx, path); err != nil {
-		return storagedriver.PathNotFoundError{DriverName: d.Name(), Path: path}
+		return d.pathError(path, err)
+	}
+	d.pins.drop(ctx, path)
+	d.stats.drop(path)
+	if err := d.chargeQuota(ctx, removed, nil); err != nil {
+		return d.pathError(path, err)
 	}
+	if err := d.removeUploads(ctx, path); err != nil {
+		return d.pathError(path, err)
+	}
+	d.audit(ctx, AuditDelete, path, "", oldRef, swarm.ZeroAddress)
+	d.notify(EventDelete, path, "", swarm.ZeroAddress)
 	logger.Debug("Successfully deleted path", slog.String("path", path))
 	return nil
 }
 
 // Move moves an object stored at sourcePath to destPath, removing the original
 func (d *swarmDriver) Move(ctx context.Context, sourcePath string, destPath string) error {
+	if err := d.acquire(); err != nil {
+		return d.pathError(sourcePath, err)
+	}
+	defer d.release()
+	if d.readOnly {
+		return d.pathError(sourcePath, ErrReadOnly)
+	}
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 	logger.Debug("Move Hit", slog.String("sourcePath", sourcePath), slog.String("destPath", destPath))
-	// 1. Lookup and read source metadata
-	sourceMeta, err := d.getMetadata(ctx, sourcePath)
-	if err != nil {
+	// 1. Check that the source exists
+	if _, err := d.getMetadata(ctx, sourcePath); err != nil {
 		logger.Error("Move: Failed to lookup source Metadata path", slog.String("path", sourcePath), slog.String("error", err.Error()))
-		return storagedriver.PathNotFoundError{Path: sourcePath, DriverName: d.Name()}
+		return d.pathError(sourcePath, err)
+	}
+	dataRef, err := d.auditDataRef(ctx, sourcePath)
+	if err != nil {
+		return d.pathError(sourcePath, err)
+	}
+	moved, err := d.quotaFiles(ctx, sourcePath)
+	if err != nil {
+		return d.pathError(sourcePath, err)
+	}
+	removed, err := d.quotaFiles(ctx, destPath)
+	if err != nil {
+		return d.pathError(destPath, err)
 	}
 	// 2. Remove entry from the source parent
 	sourceParentPath := filepath.ToSlash(filepath.Dir(sourcePath))
 	sourceParentMeta, err := d.getMetadata(ctx, sourceParentPath)
 	if err != nil {
 		logger.Error("Move: Failed to get source parent Metadata", slog.String("path", sourcePath), slog.String("error", err.Error()))
-		return storagedriver.PathNotFoundError{Path: sourceParentPath, DriverName: d.Name()}
+		return d.pathError(sourceParentPath, err)
 	}
 	sourceParentMeta.Children = removeFromSlice(sourceParentMeta.Children, filepath.Base(sourcePath))
+	delete(sourceParentMeta.Refs, filepath.Base(sourcePath))
 	if err := d.putMetadata(ctx, sourceParentPath, sourceParentMeta); err != nil {
 		logger.Error("Move: Failed to update source parent Metadata", slog.String("path", sourceParentPath), slog.String("error", err.Error()))
-		return storagedriver.PathNotFoundError{Path: sourcePath, DriverName: d.Name()}
+		return d.pathError(sourcePath, err)
 	}
-	// 3. Add entry to the destination parent
-	destParentPath := filepath.ToSlash(filepath.Dir(destPath))
-	destParentMeta, err := d.getMetadata(ctx, destParentPath)
+	// 3. Move data and metadata recursively from source to destination
+	ref, err := d.moveDataRecursively(ctx, sourcePath, destPath)
 	if err != nil {
-		logger.Warn("Move: Destination parent not found, creating new metadata", slog.String("path", destParentPath), slog.String("error", err.Error()))
-		destParentMeta = metaData{
-			IsDir:    true,
-			Path:     destParentPath,
-			ModTime:  time.Now().Unix(),
-			Children: []string{},
-		}
+		logger.Error("Move: Failed to move data recursively", slog.String("sourcePath", sourcePath), slog.String("destPath", destPath), slog.String("error", err.Error()))
+		return d.pathError(destPath, err)
 	}
-	destParentMeta.Children = append(destParentMeta.Children, filepath.Base(destPath))
-	if err := d.putMetadata(ctx, destParentPath, destParentMeta); err != nil {
-		logger.Error("Move: Failed to update destination parent Metadata", slog.String("path", destParentPath), slog.String("error", err.Error()))
-		return storagedriver.PathNotFoundError{Path: sourcePath, DriverName: d.Name()}
+	// 4. Add the entry to the destination parents
+	if err := d.linkParents(ctx, destPath, ref); err != nil {
+		logger.Error("Move: Failed to update destination parent Metadata", slog.String("path", destPath), slog.String("error", err.Error()))
+		return d.pathError(sourcePath, err)
 	}
-	// 4. Update metadata to the new destination path
-	sourceMeta.Path = destPath
-	if err := d.putMetadata(ctx, destPath, sourceMeta); err != nil {
-		logger.Error("Move: Failed to update Metadata to new destination path", slog.String("path", destPath), slog.String("error", err.Error()))
-		return storagedriver.PathNotFoundError{Path: sourcePath, DriverName: d.Name()}
+	d.pins.move(ctx, sourcePath, destPath)
+	d.stats.move(sourcePath, destPath)
+	added := make(map[string]int64, len(moved))
+	for path, size := range moved {
+		removed[path] = size
+		added[destPath+strings.TrimPrefix(path, sourcePath)] = size
 	}
-	// 5. Move data recursively from source to destination
-	err = d.moveDataRecursively(ctx, sourcePath, destPath)
-	if err != nil {
-		logger.Error("Move: Failed to move data recursively", slog.String("sourcePath", sourcePath), slog.String("destPath", destPath), slog.String("error", err.Error()))
-		return storagedriver.PathNotFoundError{Path: destParentPath, DriverName: d.Name()}
+	if err := d.chargeQuota(ctx, removed, added); err != nil {
+		return d.pathError(sourcePath, err)
 	}
+	if err := d.removeUploads(ctx, sourcePath); err != nil {
+		return d.pathError(sourcePath, err)
+	}
+	d.audit(ctx, AuditMove, sourcePath, destPath, dataRef, dataRef)
+	d.notify(EventMove, sourcePath, destPath, dataRef)
 	logger.Debug("Move Success", slog.String("sourcePath", sourcePath), slog.String("destPath", destPath))
 	return nil
 }
 
-func (d *swarmDriver) moveDataRecursively(ctx context.Context, sourcePath, destPath string) error {
+// moveDataRecursively republishes the feeds of sourcePath and its
+// descendants under destPath, children before their parent so that each
+// directory records the references of its moved children, and returns the
+// references of destPath.
+func (d *swarmDriver) moveDataRecursively(ctx context.Context, sourcePath, destPath string) (childRef, error) {
+	var ref childRef
 	// Get metadata of the source path
 	sourceMetadata, err := d.getMetadata(ctx, sourcePath)
 	if err != nil {
-		return fmt.Errorf("Move: failed to lookup source metadata: %v", err)
+		return ref, fmt.Errorf("Move: failed to lookup source metadata: %w", err)
 	}
 	// Update the metadata's path field to reflect the new destination
 	sourceMetadata.Path = destPath
 	// Move the data reference for the current path
 	dataRef, err := d.lookuper.Get(ctx, filepath.Join(sourcePath, "data"), time.Now().Unix())
-	if err != nil {
-		return fmt.Errorf("Move: failed to get data reference: %v", err)
-	}
-	// Publish data reference to destination
-	err = d.publisher.Put(ctx, filepath.Join(destPath, "data"), time.Now().Unix(), dataRef)
-	if err != nil {
-		return fmt.Errorf("Move: failed to publish data reference to destination: %v", err)
-	}
-	metaBuf, err := json.Marshal(sourceMetadata)
-	if err != nil {
-		return fmt.Errorf("putMetadata: failed to marshal metadata: %v", err)
-	}
-	// Publish the updated metadata to the destination
-	metaRef, err := d.splitter.Split(ctx, io.NopCloser(bytes.NewReader(metaBuf)), int64(len(metaBuf)), d.encrypt)
-	if err != nil || isZeroAddress(metaRef) {
-		return fmt.Errorf("putMetadata: failed to split metadata: %v", err)
-	}
-	err = d.publisher.Put(ctx, filepath.Join(destPath, "mtdt"), time.Now().Unix(), metaRef)
-	if err != nil {
-		return fmt.Errorf("putMetadata: failed to publish metadata: %v", err)
+	switch {
+	case err == nil:
+		// Publish data reference to destination
+		err = d.publisher.Put(ctx, filepath.Join(destPath, "data"), time.Now().Unix(), dataRef)
+		if err != nil {
+			return ref, fmt.Errorf("Move: failed to publish data reference to destination: %w", err)
+		}
+		if !isZeroAddress(dataRef) {
+			ref.Data = dataRef
+		}
+	case sourceMetadata.IsDir && errors.Is(err, lookuper.ErrNotFound):
+		// Directories have no data feed.
+	default:
+		return ref, fmt.Errorf("Move: failed to get data reference: %w", err)
 	}
 	// Recursively handle children
 	for _, child := range sourceMetadata.Children {
 		sourceChildPath := filepath.Join(sourcePath, child)
 		destChildPath := filepath.Join(destPath, child)
 		// Recursively move each child
-		err := d.moveDataRecursively(ctx, sourceChildPath, destChildPath)
+		moved, err := d.moveDataRecursively(ctx, sourceChildPath, destChildPath)
 		if err != nil {
-			return fmt.Errorf("Move: failed to move child data from %s to %s: %v", sourceChildPath, destChildPath, err)
+			return ref, fmt.Errorf("Move: failed to move child data from %s to %s: %w", sourceChildPath, destChildPath, err)
+		}
+		if sourceMetadata.Refs == nil {
+			sourceMetadata.Refs = make(map[string]childRef)
 		}
+		sourceMetadata.Refs[child] = moved
 	}
-	return nil
+	metaBuf, err := json.Marshal(sourceMetadata)
+	if err != nil {
+		return ref, fmt.Errorf("putMetadata: failed to marshal metadata: %w", err)
+	}
+	// Publish the updated metadata to the destination
+	ref.Meta, err = d.split(ctx, metaBuf)
+	if err != nil {
+		return ref, fmt.Errorf("putMetadata: failed to split metadata: %w", err)
+	}
+	err = d.publisher.Put(ctx, filepath.Join(destPath, "mtdt"), time.Now().Unix(), ref.Meta)
+	if err != nil {
+		return ref, fmt.Errorf("putMetadata: failed to publish metadata: %w", err)
+	}
+	return ref, nil
 }
 
 func removeFromSlice(slice []string, item string) []string {
@@ -768,25 +1176,34 @@ func (d *swarmDriver) Walk(ctx context.Context, path string, f storagedriver.Wal
 // swarmFile represents a file in the swarm storage system.
 // swarmFile represents a file in the swarm storage system.
 type swarmFile struct {
-	d         *swarmDriver  // Reference to the swarmDriver instance.
-	path      string        // Path of the file in the storage system.
-	buffer    *bytes.Buffer // Buffer to hold the file data.
-	closed    bool          // Indicates if the file has been closed.
-	committed bool          // Indicates if the file has been committed.
-	cancelled bool          // Indicates if the file operation has been cancelled.
-	offset    int64         // Offset for reading/writing data.
-	size      int64         // Size of the file.
+	d         *swarmDriver    // Reference to the swarmDriver instance.
+	ctx       context.Context // Context the writer was opened with.
+	path      string          // Path of the file in the storage system.
+	buffer    *bytes.Buffer   // Buffer to hold the file data.
+	closed    bool            // Indicates if the file has been closed.
+	committed bool            // Indicates if the file has been committed.
+	cancelled bool            // Indicates if the file operation has been cancelled.
+	offset    int64           // Offset for reading/writing data.
+	size      int64           // Size of the file.
 }
 
 // Writer returns a FileWriter which will store the content written to it
 // at the location designated by "path" after the call to Commit.
 func (d *swarmDriver) Writer(ctx context.Context, path string, append bool) (storagedriver.FileWriter, error) {
+	if err := d.acquire(); err != nil {
+		return nil, d.pathError(path, err)
+	}
+	defer d.release()
+	if d.readOnly {
+		return nil, d.pathError(path, ErrReadOnly)
+	}
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 	logger.Debug("Writer Hit", slog.String("path", path), slog.Bool("append", append))
 	var combinedData bytes.Buffer
 	w := &swarmFile{
 		d:         d,
+		ctx:       ctx,
 		path:      path,
 		closed:    false,
 		committed: false,
@@ -797,30 +1214,32 @@ func (d *swarmDriver) Writer(ctx context.Context, path string, append bool) (sto
 		logger.Debug("Writer: Append True", slog.String("path", path))
 		// Lookup existing data at the specified path
 		oldDataRef, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
-		if err != nil && !oldDataRef.Equal(swarm.ZeroAddress) {
+		if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
 			logger.Error("Writer: Append: Failed to fetch data", slog.String("path", path), slog.String("error", err.Error()))
-			return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+			return nil, d.pathError(path, err)
 		} else if oldDataRef.Equal(swarm.ZeroAddress) {
 			logger.Warn("Writer: Append: Data reference is zero", slog.String("path", path))
 			w.buffer = &combinedData
+			d.trackWriter(w)
 			return w, nil
 		}
 		// Create a joiner to read the existing data
 		oldDataJoiner, _, err := joiner.New(ctx, d.store, oldDataRef)
 		if err != nil {
 			logger.Error("Writer: Append: Failed to create joiner", slog.String("path", path), slog.String("error", err.Error()))
-			return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+			return nil, d.pathError(path, err)
 		}
 		// Copy existing data into the buffer
 		if _, err := io.Copy(&combinedData, oldDataJoiner); err != nil {
 			logger.Error("Writer: Append: Failed to copy data", slog.String("path", path), slog.String("error", err.Error()))
-			return nil, storagedriver.PathNotFoundError{Path: path, DriverName: d.Name()}
+			return nil, d.pathError(path, err)
 		}
 		logger.Debug("Writer: Append: Successfully appended data", slog.String("path", path))
 	}
 	// Set the buffer and size in the writer
 	w.buffer = &combinedData
 	w.size = int64(w.buffer.Len())
+	d.trackWriter(w)
 	logger.Debug("Writer: Success", slog.String("path", path))
 	// Return the FileWriter
 	return w, nil
@@ -828,6 +1247,10 @@ func (d *swarmDriver) Writer(ctx context.Context, path string, append bool) (sto
 
 // Write writes the provided data to the swarmFile's buffer.
 func (w *swarmFile) Write(p []byte) (int, error) {
+	if err := w.d.acquire(); err != nil {
+		return 0, fmt.Errorf("Write: %w", err)
+	}
+	defer w.d.release()
 	w.d.mutex.Lock()
 	defer w.d.mutex.Unlock()
 	// Check if the file is already closed, committed, or cancelled.
@@ -852,23 +1275,64 @@ func (w *swarmFile) Size() int64 {
 
 // Close finalizes the swarmFile, ensuring any unwritten data is stored.
 func (w *swarmFile) Close() error {
+	if err := w.d.acquire(); err != nil {
+		return fmt.Errorf("Close: %w", err)
+	}
+	defer w.d.release()
 	w.d.mutex.Lock()
 	defer w.d.mutex.Unlock()
 	logger.Debug("Close Hit", slog.String("path", w.path))
 	if w.closed {
 		return fmt.Errorf("Close: already closed")
 	}
-	// Add logic to only publish data ref if not committed and buffer has data
-	if !w.committed && w.buffer.Len() > 0 {
-		err := w.d.putData(context.Background(), w.path, w.buffer.Bytes())
-		if err != nil {
-			return fmt.Errorf("Close: failed to publish data reference: %v", err)
-		}
+	if err := w.flush(); err != nil {
+		return fmt.Errorf("Close: %w", err)
 	}
 	w.closed = true
+	w.d.untrackWriter(w)
 	return nil
 }
 
+// flush publishes the data written but not committed, so that a later
+// appending writer continues from it. It must be called with the driver's
+// mutex held.
+func (w *swarmFile) flush() error {
+	if w.committed || w.cancelled || w.buffer.Len() == 0 {
+		return nil
+	}
+	// Close has no context of its own. Keep the writer's values but not its
+	// cancellation, and bound the work by the configured timeout.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), w.d.closeTimeout)
+	defer cancel()
+	// The metadata, and with it the usage, only changes on Commit, but the
+	// data must still fit the quotas covering the path.
+	if _, err := w.d.checkQuota(ctx, w.path, int64(w.buffer.Len())); err != nil {
+		return err
+	}
+	dataRef, err := w.d.putData(ctx, w.path, w.buffer.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to publish data reference: %w", err)
+	}
+	// The data changed without the metadata.
+	w.d.stats.invalidate()
+	if err := w.d.addUpload(ctx, w.path); err != nil {
+		return err
+	}
+	// A path already in the tree records its new data in its parents.
+	metaRef, err := w.d.lookuper.Get(ctx, filepath.Join(w.path, "mtdt"), time.Now().Unix())
+	if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+		return fmt.Errorf("failed to lookup metadata reference: %w", err)
+	}
+	if err != nil || isZeroAddress(metaRef) {
+		return nil
+	}
+	ref := childRef{Meta: metaRef}
+	if !isZeroAddress(dataRef) {
+		ref.Data = dataRef
+	}
+	return w.d.linkParents(ctx, w.path, ref)
+}
+
 // Cancel aborts the swarmFile operation, discarding any unwritten data.
 // Cancel aborts the swarmFile operation, discarding any unwritten data.
 func (w *swarmFile) Cancel(ctx context.Context) error {
@@ -881,6 +1345,7 @@ func (w *swarmFile) Cancel(ctx context.Context) error {
 	}
 	// Mark the file as cancelled.
 	w.cancelled = true
+	w.d.untrackWriter(w)
 	// Set the swarmFile instance to nil to discard any unwritten data.
 	w = nil
 	return nil
@@ -888,6 +1353,10 @@ func (w *swarmFile) Cancel(ctx context.Context) error {
 
 // Commit finalizes the swarmFile, ensuring all data is stored and metadata is updated.
 func (w *swarmFile) Commit(ctx context.Context) error {
+	if err := w.d.acquire(); err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+	defer w.d.release()
 	w.d.mutex.Lock()
 	defer w.d.mutex.Unlock()
 	logger.Debug("Commit Hit", slog.String("path", w.path))
@@ -899,10 +1368,19 @@ func (w *swarmFile) Commit(ctx context.Context) error {
 	} else if w.cancelled {
 		return fmt.Errorf("Commit: already cancelled")
 	}
+	oldSize, err := w.d.checkQuota(ctx, w.path, int64(w.buffer.Len()))
+	if err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+	existed := w.d.watchedExists(ctx, w.path)
+	oldRef, err := w.d.auditDataRef(ctx, w.path)
+	if err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
 	// Use the helper function to split and store data.
-	err := w.d.putData(ctx, w.path, w.buffer.Bytes())
+	newRef, err := w.d.putData(ctx, w.path, w.buffer.Bytes())
 	if err != nil {
-		return fmt.Errorf("Commit: failed to publish data reference: %v", err)
+		return fmt.Errorf("Commit: failed to publish data reference: %w", err)
 	}
 	// Create metadata for the committed content.
 	meta := metaData{
@@ -910,11 +1388,21 @@ func (w *swarmFile) Commit(ctx context.Context) error {
 		Path:    w.path,
 		ModTime: time.Now().Unix(),
 		Size:    w.buffer.Len(),
+		Digests: w.d.digests(w.buffer.Bytes()),
 	}
 	// Store the metadata using the helper function.
 	if err := w.d.putMetadata(ctx, w.path, meta); err != nil {
-		return fmt.Errorf("Commit: failed to publish metadata reference: %v", err)
+		return fmt.Errorf("Commit: failed to publish metadata reference: %w", err)
+	}
+	if err := w.d.chargeQuota(ctx, map[string]int64{w.path: oldSize}, map[string]int64{w.path: int64(w.buffer.Len())}); err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+	w.d.stats.set(w.path, int64(w.buffer.Len()), newRef)
+	if err := w.d.removeUploads(ctx, w.path); err != nil {
+		return fmt.Errorf("Commit: %w", err)
 	}
+	w.d.audit(ctx, AuditCommit, w.path, "", oldRef, newRef)
+	w.d.notify(writeEvent(existed), w.path, "", newRef)
 	// Reset the buffer after committing data and metadata.
 	w.buffer.Reset()
 	// Mark the file as committed.
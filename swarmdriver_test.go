@@ -1,12 +1,19 @@
 package swarmdriver
 
 import (
+	"context"
+	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/Raviraj2000/swarmdriver/store"
 	"github.com/Raviraj2000/swarmdriver/store/teststore"
 	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
 	"github.com/distribution/distribution/v3/registry/storage/driver/testsuites"
 	"github.com/ethereum/go-ethereum/common"
+	beecrypto "github.com/ethersphere/bee/pkg/crypto"
+	"github.com/ethersphere/bee/pkg/swarm"
 )
 
 func newSwarmDriverConstructor() (storagedriver.StorageDriver, error) {
@@ -14,7 +21,7 @@ func newSwarmDriverConstructor() (storagedriver.StorageDriver, error) {
 	encrypt := false
 	store := teststore.NewSwarmInMemoryStore()
 
-	return New(addr, store, encrypt), nil
+	return New(context.Background(), addr, store, encrypt)
 }
 
 func TestSwarmDriverSuite(t *testing.T) {
@@ -24,3 +31,236 @@ func TestSwarmDriverSuite(t *testing.T) {
 func BenchmarkSwarmDriverSuite(b *testing.B) {
 	testsuites.BenchDriver(b, newSwarmDriverConstructor)
 }
+
+func TestSwarmDriverClose(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := d.Writer(ctx, "/open", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("pending")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/read", []byte("content")); err != nil {
+		t.Fatal(err)
+	}
+	r, err := d.Reader(ctx, "/read", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// The open writer was closed, publishing what was written to it.
+	if ref, err := d.lookuper.Get(ctx, "/open/data", time.Now().Unix()); err != nil || ref.IsZero() {
+		t.Fatalf("want data of the open writer published, got %s, %v", ref, err)
+	}
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, ErrDriverClosed) {
+		t.Fatalf("Read after Close: want ErrDriverClosed, got %v", err)
+	}
+	if err := w.Close(); !errors.Is(err, ErrDriverClosed) {
+		t.Fatalf("writer Close after Close: want ErrDriverClosed, got %v", err)
+	}
+
+	var derr storagedriver.Error
+	if err := d.PutContent(ctx, "/a", []byte("a")); !errors.As(err, &derr) || !errors.Is(derr.Detail, ErrDriverClosed) {
+		t.Fatalf("PutContent after Close: want ErrDriverClosed, got %v", err)
+	}
+	if _, err := d.Writer(ctx, "/b", false); !errors.As(err, &derr) || !errors.Is(derr.Detail, ErrDriverClosed) {
+		t.Fatalf("Writer after Close: want ErrDriverClosed, got %v", err)
+	}
+	if err := w.Commit(ctx); !errors.Is(err, ErrDriverClosed) {
+		t.Fatalf("Commit after Close: want ErrDriverClosed, got %v", err)
+	}
+	if err := d.Close(); !errors.Is(err, ErrDriverClosed) {
+		t.Fatalf("second Close: want ErrDriverClosed, got %v", err)
+	}
+}
+
+func TestSwarmDriverReopenWithSigner(t *testing.T) {
+	ctx := context.Background()
+	pk, err := beecrypto.GenerateSecp256k1Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := teststore.NewSwarmInMemoryStore()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), store, false, WithSigner(beecrypto.NewDefaultSigner(pk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("a1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("a2")); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := New(ctx, common.HexToAddress("0xabcd"), store, false, WithSigner(beecrypto.NewDefaultSigner(pk)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Owner() != d.Owner() {
+		t.Fatalf("want owner %s, got %s", d.Owner(), reopened.Owner())
+	}
+	if content, err := reopened.GetContent(ctx, "/a"); err != nil || string(content) != "a2" {
+		t.Fatalf("want a2, got %q, %v", content, err)
+	}
+	// Updates continue the existing feeds rather than starting them over.
+	if err := reopened.PutContent(ctx, "/b", []byte("b1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.PutContent(ctx, "/a", []byte("a3")); err != nil {
+		t.Fatal(err)
+	}
+	if content, err := reopened.GetContent(ctx, "/a"); err != nil || string(content) != "a3" {
+		t.Fatalf("want a3, got %q, %v", content, err)
+	}
+	if children, err := reopened.List(ctx, "/"); err != nil || len(children) != 2 {
+		t.Fatalf("want 2 children, got %v, %v", children, err)
+	}
+}
+
+// remoteStore hides that the store it wraps is local.
+type remoteStore struct {
+	store.PutGetter
+}
+
+func TestFactoryValidatesRemoteStores(t *testing.T) {
+	ctx := context.Background()
+	forged := swarm.NewChunk(swarm.MustParseHexAddress("00000000000000000000000000000000000000000000000000000000000000ff"), []byte("forged"))
+	for _, tc := range []struct {
+		name     string
+		store    store.PutGetter
+		params   map[string]interface{}
+		validate bool
+	}{
+		{name: "local", store: teststore.NewSwarmInMemoryStore()},
+		{name: "local enabled", store: teststore.NewSwarmInMemoryStore(), params: map[string]interface{}{"validate": true}, validate: true},
+		{name: "remote", store: remoteStore{teststore.NewSwarmInMemoryStore()}, validate: true},
+		{name: "remote disabled", store: remoteStore{teststore.NewSwarmInMemoryStore()}, params: map[string]interface{}{"validate": false}},
+	} {
+		params := map[string]interface{}{"addr": common.HexToAddress("0xabcd"), "store": tc.store, "encrypt": false}
+		for k, v := range tc.params {
+			params[k] = v
+		}
+		d, err := (&swarmDriverFactory{}).Create(ctx, params)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if err := tc.store.Put(ctx, forged); err != nil {
+			t.Fatal(err)
+		}
+		var invalid store.InvalidChunkError
+		_, err = d.(*swarmDriver).store.Get(ctx, forged.Address())
+		if got := errors.As(err, &invalid); got != tc.validate {
+			t.Fatalf("%s: want validation %v, got error %v", tc.name, tc.validate, err)
+		}
+	}
+}
+
+// stallingStore blocks puts until their context is done once stalled.
+type stallingStore struct {
+	store.PutGetter
+	stalled atomic.Bool
+}
+
+func (s *stallingStore) Put(ctx context.Context, ch swarm.Chunk) error {
+	if s.stalled.Load() {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return s.PutGetter.Put(ctx, ch)
+}
+
+func TestSwarmDriverNewContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fs := &faultyStore{PutGetter: teststore.NewSwarmInMemoryStore()}
+	if _, err := New(ctx, common.HexToAddress("0xabcd"), fs, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("New with cancelled context: want context.Canceled, got %v", err)
+	}
+}
+
+func TestSwarmFileCloseTimeout(t *testing.T) {
+	s := &stallingStore{PutGetter: teststore.NewSwarmInMemoryStore()}
+	d, err := New(context.Background(), common.HexToAddress("0xabcd"), s, false, WithCloseTimeout(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Close does not inherit the cancellation of the writer's context.
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := d.Writer(ctx, "/closed", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("pending")); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close after the writer's context was cancelled: %v", err)
+	}
+
+	// A stalled store fails Close once the timeout has passed.
+	w, err = d.Writer(context.Background(), "/stalled", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("pending")); err != nil {
+		t.Fatal(err)
+	}
+	s.stalled.Store(true)
+	start := time.Now()
+	if err := w.Close(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Close: want context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Close took %s", elapsed)
+	}
+
+	// A timeout that is not positive is refused.
+	if _, err := New(context.Background(), common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false, WithCloseTimeout(0)); err == nil {
+		t.Fatal("New: want error for zero close timeout")
+	}
+	params := map[string]interface{}{"addr": common.HexToAddress("0xabcd"), "store": teststore.NewSwarmInMemoryStore(), "encrypt": false, "closetimeout": "-1s"}
+	if _, err := (&swarmDriverFactory{}).Create(context.Background(), params); err == nil {
+		t.Fatal("Create: want error for negative closetimeout")
+	}
+}
+
+func TestSwarmDriverSetModTime(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/a", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := d.SetModTime(ctx, "/a", modTime); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := d.Stat(ctx, "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.ModTime().Equal(modTime) || fi.Size() != 1 {
+		t.Fatalf("want modification time %s, got %+v", modTime, fi)
+	}
+	entries, err := d.AuditLog(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := entries[len(entries)-1]; last.Op != AuditSetModTime || last.Path != "/a" || !last.OldRef.Equal(last.NewRef) {
+		t.Fatalf("unexpected audit entry %+v", last)
+	}
+	var notFound storagedriver.PathNotFoundError
+	if err := d.SetModTime(ctx, "/missing", modTime); !errors.As(err, &notFound) {
+		t.Fatalf("want PathNotFoundError, got %v", err)
+	}
+}
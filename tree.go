@@ -0,0 +1,141 @@
+package swarmdriver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/file/joiner"
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// errSkipSubtree is returned by a walkTree callback to skip the children of
+// the visited directory.
+var errSkipSubtree = errors.New("skip subtree")
+
+// treeNode is a path of the metadata tree together with the references its
+// feeds currently resolve to.
+type treeNode struct {
+	Path    string        // Path of the node.
+	Meta    metaData      // Decoded metadata of the node.
+	MetaRef swarm.Address // Reference published on the mtdt feed.
+	DataRef swarm.Address // Reference published on the data feed, zero for directories and empty files.
+}
+
+// resolveNode looks up the metadata and data references of path through
+// d.lookuper. It fails with lookuper.ErrNotFound if the path has no metadata.
+func (d *swarmDriver) resolveNode(ctx context.Context, path string) (treeNode, error) {
+	return d.resolveNodeCached(ctx, path, nil)
+}
+
+// resolveNodeCached is resolveNode with decoded metadata memoized by
+// reference in cache, which may be nil.
+func (d *swarmDriver) resolveNodeCached(ctx context.Context, path string, cache map[string]metaData) (treeNode, error) {
+	path = filepath.ToSlash(path)
+	node := treeNode{Path: path}
+	metaRef, err := d.lookuper.Get(ctx, filepath.Join(path, "mtdt"), time.Now().Unix())
+	if err != nil {
+		return node, fmt.Errorf("resolveNode: failed to lookup metadata for path %s: %w", path, err)
+	}
+	if isZeroAddress(metaRef) {
+		return node, fmt.Errorf("resolveNode: metadata for path %s deleted: %w", path, lookuper.ErrNotFound)
+	}
+	node.MetaRef = metaRef
+	if meta, ok := cache[metaRef.ByteString()]; ok {
+		node.Meta = meta
+	} else {
+		if err := d.getJSON(ctx, metaRef, &node.Meta); err != nil {
+			return node, fmt.Errorf("resolveNode: failed to read metadata for path %s: %w", path, err)
+		}
+		if cache != nil {
+			cache[metaRef.ByteString()] = node.Meta
+		}
+	}
+	if node.Meta.IsDir {
+		return node, nil
+	}
+	dataRef, err := d.lookuper.Get(ctx, filepath.Join(path, "data"), time.Now().Unix())
+	if err != nil && !errors.Is(err, lookuper.ErrNotFound) {
+		return node, fmt.Errorf("resolveNode: failed to lookup data for path %s: %w", path, err)
+	}
+	if err == nil && !isZeroAddress(dataRef) {
+		node.DataRef = dataRef
+	}
+	return node, nil
+}
+
+// walkTree visits path and all its descendants depth first, parents before
+// children. Children listed by a directory whose metadata cannot be found are
+// skipped. If fn returns errSkipSubtree for a directory its children are not
+// visited; any other error stops the walk.
+func (d *swarmDriver) walkTree(ctx context.Context, path string, fn func(treeNode) error) error {
+	node, err := d.resolveNode(ctx, path)
+	if err != nil {
+		return err
+	}
+	return d.walkNode(ctx, node, fn)
+}
+
+func (d *swarmDriver) walkNode(ctx context.Context, node treeNode, fn func(treeNode) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := fn(node); err != nil {
+		if errors.Is(err, errSkipSubtree) {
+			return nil
+		}
+		return err
+	}
+	if !node.Meta.IsDir {
+		return nil
+	}
+	for _, child := range node.Meta.Children {
+		childNode, err := d.resolveNode(ctx, filepath.Join(node.Path, child))
+		if errors.Is(err, lookuper.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := d.walkNode(ctx, childNode, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putJSON stores the JSON encoding of v in the chunk store and returns its
+// reference.
+func (d *swarmDriver) putJSON(ctx context.Context, v interface{}) (swarm.Address, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return swarm.ZeroAddress, fmt.Errorf("putJSON: failed to marshal: %w", err)
+	}
+	ref, err := d.split(ctx, buf)
+	if err != nil {
+		return swarm.ZeroAddress, fmt.Errorf("putJSON: failed to split: %w", err)
+	}
+	return ref, nil
+}
+
+// getJSON reads the document stored at ref and decodes it into v.
+func (d *swarmDriver) getJSON(ctx context.Context, ref swarm.Address, v interface{}) error {
+	j, _, err := joiner.New(ctx, d.store, ref)
+	if err != nil {
+		return fmt.Errorf("getJSON: failed to create joiner: %w", err)
+	}
+	buf, err := io.ReadAll(j)
+	if err != nil {
+		return fmt.Errorf("getJSON: failed to read: %w", err)
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return fmt.Errorf("getJSON: failed to unmarshal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// uploadsFeed is the feed holding the reference of the list of paths whose
+// data was published by closing a writer but not committed yet. Like the
+// other driver feeds it cannot collide with a path.
+const uploadsFeed = "swarmdriver/uploads"
+
+// loadUploads reads the paths of the pending uploads, which are empty until a
+// writer is first closed without committing.
+func (d *swarmDriver) loadUploads(ctx context.Context) (map[string]struct{}, error) {
+	uploads := make(map[string]struct{})
+	ref, err := d.lookuper.Get(ctx, uploadsFeed, time.Now().Unix())
+	if errors.Is(err, lookuper.ErrNotFound) || (err == nil && isZeroAddress(ref)) {
+		return uploads, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup uploads: %w", err)
+	}
+	var list []string
+	if err := d.getJSON(ctx, ref, &list); err != nil {
+		return nil, fmt.Errorf("failed to read uploads: %w", err)
+	}
+	for _, path := range list {
+		uploads[path] = struct{}{}
+	}
+	return uploads, nil
+}
+
+// storeUploads writes the paths of the pending uploads and publishes them.
+func (d *swarmDriver) storeUploads(ctx context.Context) error {
+	list := make([]string, 0, len(d.uploads))
+	for path := range d.uploads {
+		list = append(list, path)
+	}
+	sort.Strings(list)
+	ref, err := d.putJSON(ctx, list)
+	if err != nil {
+		return fmt.Errorf("failed to store uploads: %w", err)
+	}
+	if err := d.publisher.Put(ctx, uploadsFeed, time.Now().Unix(), ref); err != nil {
+		return fmt.Errorf("failed to publish uploads: %w", err)
+	}
+	return nil
+}
+
+// addUpload records that the data feed of path holds data that was not
+// committed, so that GC keeps it for a writer resuming the upload.
+func (d *swarmDriver) addUpload(ctx context.Context, path string) error {
+	if _, ok := d.uploads[path]; ok {
+		return nil
+	}
+	d.uploads[path] = struct{}{}
+	return d.storeUploads(ctx)
+}
+
+// removeUploads forgets the pending uploads at or below path, whose data
+// was committed, overwritten or deleted.
+func (d *swarmDriver) removeUploads(ctx context.Context, path string) error {
+	changed := false
+	for upload := range d.uploads {
+		if underPrefix(upload, path) {
+			delete(d.uploads, upload)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return d.storeUploads(ctx)
+}
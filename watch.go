@@ -0,0 +1,341 @@
+package swarmdriver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethersphere/bee/pkg/swarm"
+
+	"github.com/Raviraj2000/swarmdriver/lookuper"
+)
+
+// defaultWatchBuffer is the number of events a watcher buffers when no size
+// is configured.
+const defaultWatchBuffer = 64
+
+// EventKind classifies an Event.
+type EventKind string
+
+const (
+	EventCreate   EventKind = "create"   // A file was written to a path that did not exist.
+	EventUpdate   EventKind = "update"   // An existing file was overwritten.
+	EventDelete   EventKind = "delete"   // A path was deleted.
+	EventMove     EventKind = "move"     // A path was moved to Dest.
+	EventOverflow EventKind = "overflow" // Events were dropped because the consumer fell behind.
+)
+
+// Event describes a change below a watched prefix.
+type Event struct {
+	Kind EventKind     // Kind of the change.
+	Path string        // Path that changed, the source for moves.
+	Dest string        // Destination of a move.
+	Ref  swarm.Address // Data reference after the change, zero if there is none.
+	Time time.Time     // Time the change was observed.
+}
+
+// WatchOptions configures a watch.
+type WatchOptions struct {
+	// Buffer is the number of events buffered for a slow consumer. Once it is
+	// full further events are dropped and the consumer receives a single
+	// EventOverflow as soon as there is room again, after which it should
+	// resynchronize, for example with List or Diff. Defaults to 64.
+	Buffer int
+	// PollInterval switches the watch to polling: the feeds of the prefix are
+	// resolved through the lookuper every interval and changes made by any
+	// writer of the feeds, including other replicas, are reported. Since a
+	// directory records the references of its children, a poll only descends
+	// into directories whose reference changed, and an unchanged tree costs a
+	// single lookup. Polling reports files only and cannot tell moves from a
+	// delete and a create. Zero delivers events for mutations made through
+	// this driver.
+	PollInterval time.Duration
+}
+
+// watcher is a single subscription.
+type watcher struct {
+	prefix   string
+	mu       sync.Mutex // Guards events, closed and overflow.
+	events   chan Event
+	done     chan struct{} // Closed together with events.
+	closed   bool
+	overflow bool // Set when an event was dropped and not yet reported.
+}
+
+// matches reports whether path is at or below the watched prefix.
+func (w *watcher) matches(path string) bool {
+	return w.prefix == "/" || path == w.prefix || strings.HasPrefix(path, w.prefix+"/")
+}
+
+// send delivers ev without blocking, recording an overflow if the buffer is
+// full.
+func (w *watcher) send(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	if w.overflow {
+		select {
+		case w.events <- Event{Kind: EventOverflow, Path: w.prefix, Time: time.Now()}:
+			w.overflow = false
+		default:
+			return
+		}
+	}
+	select {
+	case w.events <- ev:
+	default:
+		w.overflow = true
+	}
+}
+
+func (w *watcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		close(w.events)
+		close(w.done)
+	}
+}
+
+// watchHub holds the in-process watchers of a driver and its views.
+type watchHub struct {
+	mu       sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{watchers: make(map[*watcher]struct{})}
+}
+
+func (h *watchHub) add(w *watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.watchers[w] = struct{}{}
+}
+
+func (h *watchHub) remove(w *watcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.watchers, w)
+}
+
+// active reports whether anyone is listening for in-process events.
+func (h *watchHub) active() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.watchers) > 0
+}
+
+// emit hands ev to every watcher whose prefix matches its path or, for moves,
+// its destination.
+func (h *watchHub) emit(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for w := range h.watchers {
+		if w.matches(ev.Path) || (ev.Dest != "" && w.matches(ev.Dest)) {
+			w.send(ev)
+		}
+	}
+}
+
+// closeAll ends every watch, used when the driver is closed.
+func (h *watchHub) closeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for w := range h.watchers {
+		w.close()
+		delete(h.watchers, w)
+	}
+}
+
+// watchedExists reports whether path has metadata, so that writes can be told
+// apart as creates and updates. It does no work if nobody is watching.
+func (d *swarmDriver) watchedExists(ctx context.Context, path string) bool {
+	if !d.watch.active() {
+		return false
+	}
+	_, err := d.getMetadata(ctx, path)
+	return err == nil
+}
+
+// notify emits an in-process event for a successful mutation.
+func (d *swarmDriver) notify(kind EventKind, path, dest string, ref swarm.Address) {
+	d.watch.emit(Event{Kind: kind, Path: path, Dest: dest, Ref: ref, Time: time.Now()})
+}
+
+// writeEvent is the kind of event for a write to a path that existed before.
+func writeEvent(existed bool) EventKind {
+	if existed {
+		return EventUpdate
+	}
+	return EventCreate
+}
+
+// Watch subscribes to changes at or below prefix. The returned channel is
+// closed when ctx is done or the driver is closed.
+func (d *swarmDriver) Watch(ctx context.Context, prefix string, opts WatchOptions) (<-chan Event, error) {
+	if err := d.acquire(); err != nil {
+		return nil, d.pathError(prefix, err)
+	}
+	defer d.release()
+	logger.Debug("Watch Hit", slog.String("prefix", prefix))
+	if prefix != "/" {
+		if err := isValidPath(prefix); err != nil {
+			return nil, d.pathError(prefix, err)
+		}
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultWatchBuffer
+	}
+	w := &watcher{prefix: prefix, events: make(chan Event, opts.Buffer), done: make(chan struct{})}
+	if opts.PollInterval > 0 {
+		// Resolve the starting state now so that only later changes are
+		// reported.
+		d.mutex.RLock()
+		state, err := d.pollTree(ctx, prefix, nil)
+		d.mutex.RUnlock()
+		if err != nil {
+			return nil, d.pathError(prefix, err)
+		}
+		go d.poll(ctx, w, state, opts.PollInterval)
+		return w.events, nil
+	}
+	d.watch.add(w)
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.watch.remove(w)
+			w.close()
+		case <-w.done:
+		}
+	}()
+	return w.events, nil
+}
+
+// polledNode is the state of a path as last seen by a polling watch.
+type polledNode struct {
+	treeNode
+	children map[string]*polledNode // Children of a directory by name.
+}
+
+// pollTree resolves the state of the subtree at path, nil if the path does
+// not exist. Directories whose reference matches their state in old are not
+// descended and keep it.
+func (d *swarmDriver) pollTree(ctx context.Context, path string, old *polledNode) (*polledNode, error) {
+	if old != nil && old.Meta.IsDir && old.Meta.Refs != nil {
+		ref, err := d.lookuper.Get(ctx, filepath.Join(path, "mtdt"), time.Now().Unix())
+		if err == nil && ref.Equal(old.MetaRef) {
+			return old, nil
+		}
+	}
+	node, err := d.resolveNode(ctx, path)
+	if errors.Is(err, lookuper.ErrNotFound) {
+		// The path may be created later.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &polledNode{treeNode: node}
+	if !node.Meta.IsDir {
+		return p, nil
+	}
+	p.children = make(map[string]*polledNode, len(node.Meta.Children))
+	for _, child := range node.Meta.Children {
+		var oc *polledNode
+		if old != nil {
+			oc = old.children[child]
+		}
+		c, err := d.pollTree(ctx, filepath.ToSlash(filepath.Join(path, child)), oc)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			p.children[child] = c
+		}
+	}
+	return p, nil
+}
+
+// poll reports the changes between successive pollTree results until ctx is
+// done or the driver is closed.
+func (d *swarmDriver) poll(ctx context.Context, w *watcher, state *polledNode, interval time.Duration) {
+	defer w.close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := d.acquire(); err != nil {
+			return
+		}
+		d.mutex.RLock()
+		next, err := d.pollTree(ctx, w.prefix, state)
+		d.mutex.RUnlock()
+		d.release()
+		if err != nil {
+			// Keep the last known state and try again on the next tick.
+			logger.Warn("Watch: Poll failed", slog.String("prefix", w.prefix), slog.String("error", err.Error()))
+			continue
+		}
+		sendChanges(w, state, next, time.Now())
+		state = next
+	}
+}
+
+// sendChanges sends the events for the files that differ between the old
+// and the new state of a subtree, either of which may be nil.
+func sendChanges(w *watcher, old, next *polledNode, now time.Time) {
+	switch {
+	case old == next:
+		return
+	case old == nil:
+		walkPolled(next, func(n *polledNode) {
+			w.send(Event{Kind: EventCreate, Path: n.Path, Ref: n.DataRef, Time: now})
+		})
+		return
+	case next == nil:
+		walkPolled(old, func(n *polledNode) {
+			w.send(Event{Kind: EventDelete, Path: n.Path, Time: now})
+		})
+		return
+	case old.Meta.IsDir != next.Meta.IsDir:
+		sendChanges(w, old, nil, now)
+		sendChanges(w, nil, next, now)
+		return
+	case !next.Meta.IsDir:
+		if !old.MetaRef.Equal(next.MetaRef) || !old.DataRef.Equal(next.DataRef) {
+			w.send(Event{Kind: EventUpdate, Path: next.Path, Ref: next.DataRef, Time: now})
+		}
+		return
+	}
+	for name, c := range next.children {
+		sendChanges(w, old.children[name], c, now)
+	}
+	for name, c := range old.children {
+		if _, ok := next.children[name]; !ok {
+			sendChanges(w, c, nil, now)
+		}
+	}
+}
+
+// walkPolled calls fn with every file at or below n.
+func walkPolled(n *polledNode, fn func(*polledNode)) {
+	if !n.Meta.IsDir {
+		fn(n)
+		return
+	}
+	for _, c := range n.children {
+		walkPolled(c, fn)
+	}
+}
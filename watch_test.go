@@ -0,0 +1,137 @@
+package swarmdriver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Raviraj2000/swarmdriver/store/teststore"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := d.Watch(ctx, "/repo", WatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/other/x", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/a", []byte("a1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.PutContent(ctx, "/repo/a", []byte("a2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Move(ctx, "/repo/a", "/repo/b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(ctx, "/repo/b"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []Event{
+		{Kind: EventCreate, Path: "/repo/a"},
+		{Kind: EventUpdate, Path: "/repo/a"},
+		{Kind: EventMove, Path: "/repo/a", Dest: "/repo/b"},
+		{Kind: EventDelete, Path: "/repo/b"},
+	} {
+		got := <-events
+		if got.Kind != want.Kind || got.Path != want.Path || got.Dest != want.Dest {
+			t.Fatalf("want %+v, got %+v", want, got)
+		}
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatal("want channel closed after cancel")
+	}
+}
+
+func TestWatchOverflow(t *testing.T) {
+	ctx := context.Background()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := d.Watch(ctx, "/", WatchOptions{Buffer: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/a", "/b", "/c"} {
+		if err := d.PutContent(ctx, path, []byte(path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ev := <-events; ev.Path != "/a" {
+		t.Fatalf("want /a, got %+v", ev)
+	}
+	if err := d.PutContent(ctx, "/d", []byte("d")); err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-events; ev.Kind != EventOverflow {
+		t.Fatalf("want overflow, got %+v", ev)
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-events; ok {
+		t.Fatal("want channel closed after Close")
+	}
+}
+
+func TestWatchPoll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := New(ctx, common.HexToAddress("0xabcd"), teststore.NewSwarmInMemoryStore(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/repo/a", "/repo/big/x"} {
+		if err := d.PutContent(ctx, path, []byte(path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := &recordingLookuper{Lookuper: d.lookuper}
+	d.lookuper = rec
+	events, err := d.Watch(ctx, "/repo", WatchOptions{PollInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.lookedUp("")
+	if err := d.PutContent(ctx, "/repo/b", []byte("b1")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Kind != EventCreate || ev.Path != "/repo/b" {
+			t.Fatalf("want create of /repo/b, got %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	// The unchanged directory was not descended.
+	for _, id := range rec.lookedUp("/repo/big/") {
+		if id != "/repo/big/mtdt" {
+			t.Fatalf("unchanged subtree was polled: looked up %s", id)
+		}
+	}
+	if err := d.Delete(ctx, "/repo/a"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Kind != EventDelete || ev.Path != "/repo/a" {
+			t.Fatalf("want delete of /repo/a, got %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+}